@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"go.sia.tech/hostd/v2/api"
+	"go.sia.tech/hostd/v2/host/metrics"
+)
+
+// backfillPageSize is the number of periods requested at a time from the
+// hostd period metrics endpoint
+const backfillPageSize = 500
+
+// backfillIntervals are the period lengths accepted by -interval
+var backfillIntervals = map[string]struct {
+	interval metrics.Interval
+	step     func(time.Time) time.Time
+}{
+	"hourly": {metrics.IntervalHourly, func(t time.Time) time.Time { return t.Add(time.Hour) }},
+	"daily":  {metrics.IntervalDaily, func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+}
+
+// periodCollector exports the host metrics of one period, timestamped with
+// the start of the period
+type periodCollector struct {
+	m *metrics.Metrics
+}
+
+// Describe implements prometheus.Collector. The collector is unchecked, the
+// metrics are the ones of HostdCollector.
+func (p periodCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector
+func (p periodCollector) Collect(ch chan<- prometheus.Metric) {
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.NewMetricWithTimestamp(p.m.Timestamp, prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value))
+	}
+	collectHostMetrics(gauge, p.m)
+	collectHostMetricFields(gauge, p.m)
+}
+
+// runBackfill implements the backfill command, writing the period metrics
+// stored by hostd as an OpenMetrics file for promtool
+func runBackfill(args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	configFile := fs.String("config.file", "", "Configuration file, every target of the file is backfilled")
+	passwd := fs.String("passwd", "Sia is Awesome", "Hostd API password")
+	address := fs.String("address", "127.0.0.1:9980", "Hostd API address")
+	var targetAddresses, targetPasswdFiles keyValueFlag
+	fs.Var(&targetAddresses, "target", "Hostd to backfill as name=address, can be repeated (overrides -address)")
+	fs.Var(&targetPasswdFiles, "target.passwd-file", "File holding the API password of a target as name=path, can be repeated (default -passwd)")
+	timezone := fs.String("timezone", "", "Timezone of -start and -end, as an IANA name like Europe/Madrid (default local time)")
+	start := fs.String("start", "", "First day to backfill, as YYYY-MM-DD")
+	end := fs.String("end", "", "Day the backfill stops at, excluded, as YYYY-MM-DD (default today)")
+	interval := fs.String("interval", "daily", "Period of the backfilled samples, hourly or daily")
+	output := fs.String("output", "", "OpenMetrics file to write (default standard output)")
+	var extraLabels keyValueFlag
+	fs.Var(&extraLabels, "label", "Label added to every series as name=value, like the job and instance labels of the scraped series, can be repeated")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s backfill:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if passwdEnv, isSet := os.LookupEnv("HOSTD_PASSWD"); isSet {
+		*passwd = passwdEnv
+	}
+
+	var cfg *config
+	if *configFile != "" {
+		var err error
+		cfg, err = loadConfig(*configFile, *passwd)
+		if err != nil {
+			return err
+		}
+	} else {
+		targets, err := parseTargets(&targetAddresses, &targetPasswdFiles, *address, *passwd)
+		if err != nil {
+			return err
+		}
+		cfg = &config{Timezone: *timezone, Targets: targets}
+		if err := cfg.validate(*passwd); err != nil {
+			return err
+		}
+	}
+
+	// the labels added by Prometheus when scraping, which the exporter
+	// cannot know
+	for _, name := range extraLabels.keys {
+		if err := checkLabelName(name); err != nil {
+			return fmt.Errorf("-label: %w", err)
+		}
+		for _, t := range cfg.Targets {
+			if _, ok := t.Labels[name]; ok {
+				return fmt.Errorf("-label: label name %q is already used by target %q", name, t.Name)
+			}
+		}
+	}
+
+	period, ok := backfillIntervals[*interval]
+	if !ok {
+		return fmt.Errorf("invalid interval %q, expected hourly or daily", *interval)
+	}
+	if *start == "" {
+		return errors.New("-start is required")
+	}
+	from, err := time.ParseInLocation("2006-01-02", *start, cfg.location)
+	if err != nil {
+		return fmt.Errorf("invalid start: %w", err)
+	}
+	now := time.Now().In(cfg.location)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, cfg.location)
+	if *end != "" {
+		if to, err = time.ParseInLocation("2006-01-02", *end, cfg.location); err != nil {
+			return fmt.Errorf("invalid end: %w", err)
+		}
+	}
+	if !from.Before(to) {
+		return errors.New("-start must be before -end")
+	}
+
+	families := make(map[string]*dto.MetricFamily)
+	for _, t := range cfg.Targets {
+		passwd, err := t.password()
+		if err != nil {
+			return err
+		}
+		client := api.NewClient("http://"+t.Address+"/api", passwd)
+		periods, err := fetchPeriodMetrics(client, from, to, period.interval, period.step)
+		if err != nil {
+			return fmt.Errorf("failed to read the period metrics of %q: %w", t.Name, err)
+		}
+		log.Printf("read %d periods of %q", len(periods), t.Name)
+		labels := t.labels()
+		for name, value := range extraLabels.values {
+			labels[name] = value
+		}
+
+		// a registry rejects two samples of the same series, so every
+		// period is gathered on its own and the families merged
+		for i := range periods {
+			reg := prometheus.NewRegistry()
+			prometheus.WrapRegistererWith(labels, reg).MustRegister(periodCollector{&periods[i]})
+			mfs, err := reg.Gather()
+			if err != nil {
+				return err
+			}
+			for _, mf := range mfs {
+				if merged, ok := families[mf.GetName()]; ok {
+					merged.Metric = append(merged.Metric, mf.Metric...)
+				} else {
+					families[mf.GetName()] = mf
+				}
+			}
+		}
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	return writeOpenMetrics(w, families, cfg.Metrics)
+}
+
+// fetchPeriodMetrics reads the metrics of every period between from and to
+func fetchPeriodMetrics(client *api.Client, from, to time.Time, interval metrics.Interval, step func(time.Time) time.Time) ([]metrics.Metrics, error) {
+	var periods []metrics.Metrics
+	for pageStart := from; pageStart.Before(to); {
+		// count the periods of the page, the calendar decides how long a
+		// day is
+		n, pageEnd := 0, pageStart
+		for ; n < backfillPageSize && pageEnd.Before(to); n++ {
+			pageEnd = step(pageEnd)
+		}
+		page, err := client.PeriodMetrics(pageStart, n, interval)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, page...)
+		pageStart = pageEnd
+	}
+	return periods, nil
+}
+
+// writeOpenMetrics writes the families kept by filter in the OpenMetrics
+// format, sorted by name and terminated by # EOF
+func writeOpenMetrics(w io.Writer, families map[string]*dto.MetricFamily, filter *metricFilter) error {
+	names := make([]string, 0, len(families))
+	for name := range families {
+		if filter.match(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	enc := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeOpenMetrics))
+	for _, name := range names {
+		if err := enc.Encode(families[name]); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	_, err := expfmt.FinalizeOpenMetrics(w)
+	return err
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/consensus"
+)
+
+// blockInterval is the target time between two blocks, used until enough
+// blocks have been observed
+const blockInterval = 10 * time.Minute
+
+// minBlockSpan is the number of blocks, about a day, the observed samples
+// must cover before their average replaces blockInterval. The timestamps of a
+// single consensus state only cover 10 blocks, whose average is too noisy to
+// date the forecasts.
+const minBlockSpan = 144
+
+// blockSample is the timestamp of the block at height
+type blockSample struct {
+	height    uint64
+	timestamp time.Time
+}
+
+// blockTimeEstimator estimates the time between blocks from the consensus
+// tips observed over the lookback window. Only the blocks observed since the
+// exporter started are known, so the estimate needs a day of polls first.
+type blockTimeEstimator struct {
+	mu sync.Mutex
+	// samples are sorted by height
+	samples []blockSample
+}
+
+// observe records the timestamps of the tip and of the blocks preceding it,
+// dropping the samples older than lookback
+func (e *blockTimeEstimator) observe(cs consensus.State, lookback time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	known := make(map[uint64]bool, len(e.samples))
+	for _, s := range e.samples {
+		known[s.height] = true
+	}
+	// PrevTimestamps holds the timestamps of the tip and its ancestors,
+	// most recent first
+	for i, ts := range cs.PrevTimestamps {
+		if uint64(i) > cs.Index.Height || ts.IsZero() {
+			break
+		}
+		height := cs.Index.Height - uint64(i)
+		if !known[height] {
+			e.samples = append(e.samples, blockSample{height, ts})
+		}
+	}
+	sort.Slice(e.samples, func(i, j int) bool {
+		return e.samples[i].height < e.samples[j].height
+	})
+
+	if len(e.samples) == 0 {
+		return
+	}
+
+	// drop the samples older than the lookback window, a reorg to a lower
+	// height also discards the blocks above the new tip
+	newest := e.samples[len(e.samples)-1]
+	if newest.height > cs.Index.Height {
+		newest = blockSample{cs.Index.Height, cs.PrevTimestamps[0]}
+	}
+	kept := e.samples[:0]
+	for _, s := range e.samples {
+		if s.height <= newest.height && newest.timestamp.Sub(s.timestamp) <= lookback {
+			kept = append(kept, s)
+		}
+	}
+	e.samples = kept
+}
+
+// interval returns the average time between the blocks observed in the
+// lookback window, or blockInterval until they span minBlockSpan blocks
+func (e *blockTimeEstimator) interval() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.samples) < 2 {
+		return blockInterval
+	}
+	oldest, newest := e.samples[0], e.samples[len(e.samples)-1]
+	elapsed := newest.timestamp.Sub(oldest.timestamp)
+	if newest.height-oldest.height < minBlockSpan || elapsed <= 0 {
+		return blockInterval
+	}
+	return elapsed / time.Duration(newest.height-oldest.height)
+}
+
+// blockClock converts block heights to estimated times
+type blockClock struct {
+	tip      uint64
+	tipTime  time.Time
+	interval time.Duration
+}
+
+// time estimates the time at which the block at height is mined
+func (bc blockClock) time(height uint64) time.Time {
+	return bc.tipTime.Add(time.Duration(int64(height)-int64(bc.tip)) * bc.interval)
+}
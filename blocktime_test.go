@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/core/consensus"
+	"go.sia.tech/core/types"
+)
+
+func TestBlockTimeEstimator(t *testing.T) {
+	genesis := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	const observed = 9 * time.Minute
+	// stateAt returns the consensus state at height, with blocks mined
+	// every 9 minutes
+	stateAt := func(height uint64) consensus.State {
+		cs := consensus.State{Index: types.ChainIndex{Height: height}}
+		for i := range cs.PrevTimestamps {
+			if uint64(i) > height {
+				break
+			}
+			cs.PrevTimestamps[i] = genesis.Add(time.Duration(height-uint64(i)) * observed)
+		}
+		return cs
+	}
+
+	tests := []struct {
+		name     string
+		lookback time.Duration
+		// tips are the heights of the consensus states observed in order
+		tips []uint64
+		want time.Duration
+	}{
+		{"nothing observed", 48 * time.Hour, nil, blockInterval},
+		{"single state", 48 * time.Hour, []uint64{1000}, blockInterval},
+		{"less than a day of blocks", 48 * time.Hour, tipsEvery(1000, 1130, 10), blockInterval},
+		{"a day of blocks", 48 * time.Hour, tipsEvery(1000, 1150, 10), observed},
+		{"lookback covering a day", 24 * time.Hour, tipsEvery(1000, 1400, 10), observed},
+		{"lookback shorter than a day", 12 * time.Hour, tipsEvery(1000, 1400, 10), blockInterval},
+		{"skipped blocks", 48 * time.Hour, tipsEvery(1000, 1200, 50), observed},
+		{"reorg below the samples", 48 * time.Hour, append(tipsEvery(1000, 1200, 10), 1100), blockInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e blockTimeEstimator
+			for _, tip := range tt.tips {
+				e.observe(stateAt(tip), tt.lookback)
+			}
+			if got := e.interval(); got != tt.want {
+				t.Errorf("interval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// tipsEvery returns the heights from first to last, step blocks apart
+func tipsEvery(first, last, step uint64) []uint64 {
+	var tips []uint64
+	for h := first; h <= last; h += step {
+		tips = append(tips, h)
+	}
+	return tips
+}
+
+func TestBlockClock(t *testing.T) {
+	tipTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	bc := blockClock{tip: 1000, tipTime: tipTime, interval: 10 * time.Minute}
+	tests := []struct {
+		height uint64
+		want   time.Time
+	}{
+		{1000, tipTime},
+		{1006, tipTime.Add(time.Hour)},
+		{994, tipTime.Add(-time.Hour)},
+	}
+	for _, tt := range tests {
+		if got := bc.time(tt.height); !got.Equal(tt.want) {
+			t.Errorf("time(%d) = %v, want %v", tt.height, got, tt.want)
+		}
+	}
+}
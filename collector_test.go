@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"go.sia.tech/core/consensus"
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/hostd/v2/api"
+	"go.sia.tech/hostd/v2/host/contracts"
+	"go.sia.tech/hostd/v2/host/metrics"
+)
+
+// fakeHostd serves the routes of the hostd API polled by callClient, the
+// routes set in failing answer with an error
+type fakeHostd struct {
+	mu      sync.Mutex
+	failing map[string]bool
+}
+
+// fakeHostdRoutes maps the routes of the hostd API to the endpoint label of
+// the requests using them
+var fakeHostdRoutes = map[string]string{
+	"GET /api/metrics":            endpointMetrics,
+	"GET /api/metrics/{period}":   endpointPeriodMetrics,
+	"GET /api/wallet":             endpointWallet,
+	"GET /api/wallet/events":      endpointWalletEvents,
+	"GET /api/wallet/pending":     endpointWalletPending,
+	"GET /api/volumes":            endpointVolumes,
+	"GET /api/consensus/tipstate": endpointConsensus,
+	"GET /api/consensus/network":  endpointConsensus,
+	"POST /api/contracts":         endpointContracts,
+	"POST /api/v2/contracts":      endpointV2Contracts,
+}
+
+// fail makes the routes of the given endpoints fail, and the others succeed
+func (f *fakeHostd) fail(endpoints ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing = make(map[string]bool)
+	for _, endpoint := range endpoints {
+		f.failing[endpoint] = true
+	}
+}
+
+func (f *fakeHostd) handler() http.Handler {
+	mux := http.NewServeMux()
+	respond := func(pattern string, resp func() any) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			f.mu.Lock()
+			failing := f.failing[fakeHostdRoutes[pattern]]
+			f.mu.Unlock()
+			if failing {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(resp())
+		})
+	}
+	respond("GET /api/metrics", func() any { return metrics.Metrics{} })
+	respond("GET /api/metrics/{period}", func() any { return []metrics.Metrics{} })
+	respond("GET /api/wallet", func() any {
+		return api.WalletResponse{Balance: wallet.Balance{Spendable: types.Siacoins(5), Confirmed: types.Siacoins(7)}}
+	})
+	respond("GET /api/wallet/events", func() any { return []wallet.Event{} })
+	respond("GET /api/wallet/pending", func() any { return []wallet.Event{} })
+	respond("GET /api/volumes", func() any { return []api.VolumeMeta{} })
+	respond("GET /api/consensus/network", func() any { return consensus.Network{Name: "test"} })
+	respond("GET /api/consensus/tipstate", func() any {
+		cs := consensus.State{Index: types.ChainIndex{Height: 1000}}
+		cs.PrevTimestamps[0] = time.Now()
+		return cs
+	})
+	respond("POST /api/contracts", func() any { return api.ContractsResponse{Contracts: []contracts.Contract{}} })
+	respond("POST /api/v2/contracts", func() any { return api.V2ContractsResponse{Contracts: []contracts.V2Contract{}} })
+	return mux
+}
+
+// gather returns the metric families exported by c, by name
+func gather(t *testing.T, c prometheus.Collector) map[string]*dto.MetricFamily {
+	t.Helper()
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(c); err != nil {
+		t.Fatal(err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, mf := range families {
+		byName[mf.GetName()] = mf
+	}
+	return byName
+}
+
+// byEndpoint returns the values of a family by endpoint label
+func byEndpoint(mf *dto.MetricFamily) map[string]float64 {
+	values := make(map[string]float64)
+	for _, m := range mf.GetMetric() {
+		var endpoint string
+		for _, l := range m.GetLabel() {
+			if l.GetName() == "endpoint" {
+				endpoint = l.GetValue()
+			}
+		}
+		if m.GetGauge() != nil {
+			values[endpoint] = m.GetGauge().GetValue()
+		} else {
+			values[endpoint] = m.GetCounter().GetValue()
+		}
+	}
+	return values
+}
+
+// value returns the value of the only metric of a gauge family
+func value(t *testing.T, families map[string]*dto.MetricFamily, name string) float64 {
+	t.Helper()
+	mf, ok := families[name]
+	if !ok || len(mf.GetMetric()) != 1 {
+		t.Fatalf("%s is not exported once", name)
+	}
+	return mf.GetMetric()[0].GetGauge().GetValue()
+}
+
+func TestHostdCollectorUpdate(t *testing.T) {
+	fake := &fakeHostd{}
+	srv := httptest.NewServer(fake.handler())
+	defer srv.Close()
+
+	address := strings.TrimPrefix(srv.URL, "http://")
+	cfg := &config{Timezone: "UTC", Targets: []target{{Name: "host", Address: address}}}
+	if err := cfg.validate("passwd"); err != nil {
+		t.Fatal(err)
+	}
+	collector := NewHostdCollector(address, "passwd", cfg)
+
+	health := map[string]bool{
+		"hostd_up":                    true,
+		"hostd_endpoint_up":           true,
+		"hostd_scrape_errors_total":   true,
+		"hostd_scrape_timeouts_total": true,
+	}
+	walletFamilies := []string{
+		"hostd_wallet_info",
+		"hostd_wallet_confirmed_siacoin_balance",
+		"hostd_wallet_spendable_siacoin_balance",
+		"hostd_wallet_unconfirmed_siacoin_balance",
+		"hostd_wallet_immature_siacoin_balance",
+		"hostd_wallet_total_siacoin_balance",
+		"hostd_wallet_free_siacoin_balance",
+	}
+
+	// every endpoint failing: only the health of the exporter is exported,
+	// the endpoints depending on a failed one are not requested
+	fake.fail(endpoints...)
+	if err := collector.Update(); err == nil {
+		t.Fatal("Update succeeded with every endpoint failing")
+	}
+	families := gather(t, collector)
+	for name := range families {
+		if !health[name] {
+			t.Errorf("%s exported after a failed poll", name)
+		}
+	}
+	if v := value(t, families, "hostd_up"); v != 0 {
+		t.Errorf("hostd_up = %v, want 0", v)
+	}
+	queried := map[string]float64{
+		endpointMetrics:       0,
+		endpointWallet:        0,
+		endpointWalletEvents:  0,
+		endpointWalletPending: 0,
+		endpointVolumes:       0,
+		endpointConsensus:     0,
+	}
+	if got := byEndpoint(families["hostd_endpoint_up"]); !maps.Equal(got, queried) {
+		t.Errorf("hostd_endpoint_up = %v, want %v", got, queried)
+	}
+	wantErrors := map[string]float64{
+		endpointMetrics:       1,
+		endpointPeriodMetrics: 0,
+		endpointWallet:        1,
+		endpointWalletEvents:  1,
+		endpointWalletPending: 1,
+		endpointVolumes:       1,
+		endpointConsensus:     1,
+		endpointContracts:     0,
+		endpointV2Contracts:   0,
+	}
+	if got := byEndpoint(families["hostd_scrape_errors_total"]); !maps.Equal(got, wantErrors) {
+		t.Errorf("hostd_scrape_errors_total = %v, want %v", got, wantErrors)
+	}
+
+	// every endpoint succeeding
+	fake.fail()
+	if err := collector.Update(); err != nil {
+		t.Fatal(err)
+	}
+	families = gather(t, collector)
+	if v := value(t, families, "hostd_up"); v != 1 {
+		t.Errorf("hostd_up = %v, want 1", v)
+	}
+	for endpoint, up := range byEndpoint(families["hostd_endpoint_up"]) {
+		if up != 1 {
+			t.Errorf("hostd_endpoint_up{endpoint=%q} = %v, want 1", endpoint, up)
+		}
+	}
+	if got := len(families["hostd_endpoint_up"].GetMetric()); got != len(endpoints) {
+		t.Errorf("hostd_endpoint_up exported for %d endpoints, want %d", got, len(endpoints))
+	}
+	if got := byEndpoint(families["hostd_scrape_errors_total"]); !maps.Equal(got, wantErrors) {
+		t.Errorf("hostd_scrape_errors_total = %v, want %v", got, wantErrors)
+	}
+	if _, ok := families["hostd_last_successful_scrape_timestamp_seconds"]; !ok {
+		t.Error("hostd_last_successful_scrape_timestamp_seconds not exported after a successful poll")
+	}
+	for _, name := range walletFamilies {
+		if _, ok := families[name]; !ok {
+			t.Errorf("%s not exported", name)
+		}
+	}
+	if _, ok := families["hostd_revenue_potential_actual_month"]; !ok {
+		t.Error("hostd_revenue_potential_actual_month not exported after a successful poll")
+	}
+	if v := value(t, families, "hostd_wallet_free_siacoin_balance"); v != 5 {
+		t.Errorf("hostd_wallet_free_siacoin_balance = %v, want 5", v)
+	}
+
+	// a failing endpoint only removes its own metrics
+	fake.fail(endpointWallet)
+	if err := collector.Update(); err == nil {
+		t.Fatal("Update succeeded with the wallet failing")
+	}
+	previous := families
+	families = gather(t, collector)
+	if v := value(t, families, "hostd_up"); v != 0 {
+		t.Errorf("hostd_up = %v, want 0", v)
+	}
+	for endpoint, up := range byEndpoint(families["hostd_endpoint_up"]) {
+		if want := boolToFloat64(endpoint != endpointWallet); up != want {
+			t.Errorf("hostd_endpoint_up{endpoint=%q} = %v, want %v", endpoint, up, want)
+		}
+	}
+	wantErrors[endpointWallet]++
+	if got := byEndpoint(families["hostd_scrape_errors_total"]); !maps.Equal(got, wantErrors) {
+		t.Errorf("hostd_scrape_errors_total = %v, want %v", got, wantErrors)
+	}
+	removed := make(map[string]bool)
+	for _, name := range walletFamilies {
+		removed[name] = true
+	}
+	for name := range previous {
+		if _, ok := families[name]; ok == removed[name] {
+			t.Errorf("%s exported %v, want %v", name, ok, !removed[name])
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	// embed the timezone database for systems and containers without one
+	_ "time/tzdata"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gopkg.in/yaml.v3"
+)
+
+// config is the exporter configuration, read from -config.file or built from
+// the command line flags. See config.example.yml for the documented schema.
+type config struct {
+	// Refresh is the time between two polls of a target
+	Refresh time.Duration `yaml:"refresh"`
+	// Timeout bounds a single poll of a target, Refresh when unset
+	Timeout time.Duration `yaml:"timeout"`
+	// ForecastDays is the horizon of the daily revenue forecast
+	ForecastDays int `yaml:"forecast_days"`
+	// EarnedDays is the number of past days of the realized daily revenue
+	EarnedDays int `yaml:"earned_days"`
+	// ContractsPageSize is the number of contracts requested at a time
+	ContractsPageSize int `yaml:"contracts_page_size"`
+	// V2ContractsOnly leaves the legacy v1 contracts out of every forecast
+	V2ContractsOnly bool `yaml:"v2_contracts_only"`
+	// RenterTopN is the number of renters exported on their own, the others
+	// are summed as "other"
+	RenterTopN int `yaml:"renter_top_n"`
+	// Timezone is the IANA name of the timezone used for the calendar of the
+	// forecasts, the local timezone when empty
+	Timezone string `yaml:"timezone"`
+	// BlockTimeLookback is how far back the observed blocks are used to
+	// estimate the time between blocks
+	BlockTimeLookback time.Duration `yaml:"block_time_lookback"`
+
+	Targets []target          `yaml:"targets"`
+	Modules map[string]module `yaml:"modules"`
+	Metrics *metricFilter     `yaml:"metrics"`
+
+	location *time.Location
+}
+
+// metricFilter selects the exported metric families by name. A family is
+// exported if it matches one of Include (or Include is empty) and none of
+// Exclude. Patterns are anchored regular expressions.
+type metricFilter struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// compile parses the filter patterns
+func (f *metricFilter) compile() (err error) {
+	compile := func(patterns []string) ([]*regexp.Regexp, error) {
+		res := make([]*regexp.Regexp, 0, len(patterns))
+		for _, p := range patterns {
+			re, err := regexp.Compile("^(?:" + p + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid metric filter %q: %w", p, err)
+			}
+			res = append(res, re)
+		}
+		return res, nil
+	}
+	if f.include, err = compile(f.Include); err != nil {
+		return err
+	}
+	f.exclude, err = compile(f.Exclude)
+	return err
+}
+
+// match reports whether the metric family name passes the filter
+func (f *metricFilter) match(name string) bool {
+	if f == nil {
+		return true
+	}
+	for _, re := range f.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// filteringGatherer drops the metric families rejected by filter
+type filteringGatherer struct {
+	gatherer prometheus.Gatherer
+	filter   *metricFilter
+}
+
+// Gather implements prometheus.Gatherer
+func (g filteringGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.gatherer.Gather()
+	kept := mfs[:0]
+	for _, mf := range mfs {
+		if g.filter.match(mf.GetName()) {
+			kept = append(kept, mf)
+		}
+	}
+	return kept, err
+}
+
+var labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// checkLabelName reports whether name can be added to every metric of a
+// target: it must not be reserved by prometheus nor be a label of one of the
+// exported metrics, which would make registering the collector fail
+func checkLabelName(name string) error {
+	switch {
+	case !labelNameRegexp.MatchString(name), strings.HasPrefix(name, "__"):
+		return fmt.Errorf("invalid label name %q", name)
+	case name == "host", descLabels[name]:
+		return fmt.Errorf("label name %q is already used by the exported metrics", name)
+	}
+	return nil
+}
+
+// validate applies the defaults and checks the configuration. Targets and
+// modules without credentials use passwd.
+func (c *config) validate(passwd string) error {
+	if c.Refresh == 0 {
+		c.Refresh = time.Minute
+	} else if c.Refresh < 0 {
+		return errors.New("refresh must be positive")
+	}
+	if c.Timeout == 0 {
+		c.Timeout = c.Refresh
+	} else if c.Timeout < 0 {
+		return errors.New("timeout must be positive")
+	}
+	if c.ForecastDays == 0 {
+		c.ForecastDays = 90
+	} else if c.ForecastDays < 0 || c.ForecastDays > 3650 {
+		return errors.New("forecast_days must be between 1 and 3650")
+	}
+	if c.EarnedDays == 0 {
+		c.EarnedDays = 30
+	} else if c.EarnedDays < 0 || c.EarnedDays > 3650 {
+		return errors.New("earned_days must be between 1 and 3650")
+	}
+	if c.ContractsPageSize == 0 {
+		c.ContractsPageSize = 500
+	} else if c.ContractsPageSize < 0 {
+		return errors.New("contracts_page_size must be positive")
+	}
+	if c.RenterTopN == 0 {
+		c.RenterTopN = 10
+	} else if c.RenterTopN < 0 {
+		return errors.New("renter_top_n must be positive")
+	}
+	if c.BlockTimeLookback == 0 {
+		c.BlockTimeLookback = 48 * time.Hour
+	} else if c.BlockTimeLookback < 0 {
+		return errors.New("block_time_lookback must be positive")
+	}
+	if c.Timezone == "" {
+		c.location = time.Local
+	} else if loc, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	} else {
+		c.location = loc
+	}
+
+	if len(c.Targets) == 0 {
+		return errors.New("at least one target is required")
+	}
+	names := make(map[string]bool)
+	for i := range c.Targets {
+		t := &c.Targets[i]
+		switch {
+		case t.Name == "":
+			return fmt.Errorf("target %d: name is required", i+1)
+		case names[t.Name]:
+			return fmt.Errorf("target %q: name is used more than once", t.Name)
+		case t.Address == "":
+			return fmt.Errorf("target %q: address is required", t.Name)
+		case t.Password != "" && t.PasswordFile != "":
+			return fmt.Errorf("target %q: password and password_file are mutually exclusive", t.Name)
+		case t.Password == "" && t.PasswordFile == "":
+			t.Password = passwd
+		}
+		names[t.Name] = true
+
+		for k := range t.Labels {
+			if err := checkLabelName(k); err != nil {
+				return fmt.Errorf("target %q: %w", t.Name, err)
+			}
+		}
+		// every target must export the same label names, prometheus
+		// rejects metrics of one family with different label names
+		if len(t.Labels) != len(c.Targets[0].Labels) {
+			return fmt.Errorf("target %q: all targets must define the same labels", t.Name)
+		}
+		for k := range c.Targets[0].Labels {
+			if _, ok := t.Labels[k]; !ok {
+				return fmt.Errorf("target %q: all targets must define the same labels", t.Name)
+			}
+		}
+	}
+
+	// /probe sends the credentials of a module to any requested address, so
+	// it is only enabled by the modules given explicitly
+	for name, m := range c.Modules {
+		if m.Password != "" && m.PasswordFile != "" {
+			return fmt.Errorf("module %q: password and password_file are mutually exclusive", name)
+		}
+		if m.Password == "" && m.PasswordFile == "" {
+			m.Password = passwd
+			c.Modules[name] = m
+		}
+	}
+
+	if c.Metrics == nil {
+		c.Metrics = &metricFilter{}
+	}
+	return c.Metrics.compile()
+}
+
+// loadConfig reads and validates the configuration file at path
+func loadConfig(path string, passwd string) (*config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	var cfg config
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode config file %q: %w", path, err)
+	} else if err := cfg.validate(passwd); err != nil {
+		return nil, fmt.Errorf("invalid config file %q: %w", path, err)
+	}
+	return &cfg, nil
+}
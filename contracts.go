@@ -0,0 +1,233 @@
+package main
+
+import (
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/v2/api"
+	"go.sia.tech/hostd/v2/host/contracts"
+)
+
+// revenue is an amount of revenue by category, in SC
+type revenue struct {
+	Storage float64
+	Ingress float64
+	Egress  float64
+	RPC     float64
+}
+
+// revenueCategories are the values of the category label, in the order of
+// revenue.byCategory
+var revenueCategories = []string{"storage", "ingress", "egress", "rpc"}
+
+// byCategory returns the revenue of each of revenueCategories
+func (r revenue) byCategory() []float64 {
+	return []float64{r.Storage, r.Ingress, r.Egress, r.RPC}
+}
+
+// total returns the revenue of every category
+func (r revenue) total() float64 {
+	return r.Storage + r.Ingress + r.Egress + r.RPC
+}
+
+// add returns the sum of r and o
+func (r revenue) add(o revenue) revenue {
+	return revenue{
+		Storage: r.Storage + o.Storage,
+		Ingress: r.Ingress + o.Ingress,
+		Egress:  r.Egress + o.Egress,
+		RPC:     r.RPC + o.RPC,
+	}
+}
+
+// sub returns the difference of r and o
+func (r revenue) sub(o revenue) revenue {
+	return revenue{
+		Storage: r.Storage - o.Storage,
+		Ingress: r.Ingress - o.Ingress,
+		Egress:  r.Egress - o.Egress,
+		RPC:     r.RPC - o.RPC,
+	}
+}
+
+// contract is a v1 or v2 contract normalized so that every forecast sums the
+// same population whatever the protocol version
+type contract struct {
+	ID      types.FileContractID
+	Version string
+	// Active is set while the contract is confirmed and not resolved yet
+	Active bool
+	// ProofHeight is the start of the proof window and ExpirationHeight its end
+	ProofHeight      uint64
+	ExpirationHeight uint64
+	Revenue          revenue
+	// Renewed is set once the contract has been renewed, its data and
+	// collateral then belong to its successor. A renewed v1 contract keeps
+	// the active status until its window ends.
+	Renewed bool
+
+	RenterKey        string
+	Filesize         uint64
+	LockedCollateral float64
+	// ProofValue is the part of the host payout lost if no storage proof is
+	// submitted during the proof window
+	ProofValue float64
+}
+
+// normalizeV1Contract converts a v1 contract
+func normalizeV1Contract(c contracts.Contract) contract {
+	return contract{
+		ID:               c.Revision.ParentID,
+		Version:          "v1",
+		Active:           c.Status == contracts.ContractStatusActive,
+		ProofHeight:      c.Revision.WindowStart,
+		ExpirationHeight: c.Revision.WindowEnd,
+		Renewed:          c.RenewedTo != (types.FileContractID{}),
+		RenterKey:        c.RenterKey().String(),
+		Filesize:         c.Revision.Filesize,
+		LockedCollateral: convertCurrency(c.LockedCollateral),
+		ProofValue:       convertCurrency(c.Revision.ValidHostPayout()) - convertCurrency(c.Revision.MissedHostPayout()),
+		Revenue: revenue{
+			Storage: convertCurrency(c.Usage.StorageRevenue),
+			Ingress: convertCurrency(c.Usage.IngressRevenue),
+			Egress:  convertCurrency(c.Usage.EgressRevenue),
+			// v2 has no registry, its revenue is counted as RPC
+			RPC: convertCurrency(c.Usage.RPCRevenue) +
+				convertCurrency(c.Usage.RegistryRead) +
+				convertCurrency(c.Usage.RegistryWrite),
+		},
+	}
+}
+
+// normalizeV2Contract converts a v2 contract
+func normalizeV2Contract(c contracts.V2Contract) contract {
+	return contract{
+		ID:               c.ID,
+		Version:          "v2",
+		Active:           c.Status == contracts.V2ContractStatusActive,
+		ProofHeight:      c.ProofHeight,
+		ExpirationHeight: c.ExpirationHeight,
+		Renewed:          c.Status == contracts.V2ContractStatusRenewed || c.RenewedTo != (types.FileContractID{}),
+		RenterKey:        c.RenterPublicKey.String(),
+		Filesize:         c.Filesize,
+		LockedCollateral: convertCurrency(c.TotalCollateral),
+		ProofValue:       convertCurrency(c.HostOutput.Value) - convertCurrency(c.MissedHostValue),
+		Revenue: revenue{
+			Storage: convertCurrency(c.Usage.Storage),
+			Ingress: convertCurrency(c.Usage.Ingress),
+			Egress:  convertCurrency(c.Usage.Egress),
+			RPC:     convertCurrency(c.Usage.RPC),
+		},
+	}
+}
+
+// contractCount compares the number of contracts reported by the hostd API
+// with the number actually fetched
+type contractCount struct {
+	total   int
+	fetched int
+}
+
+// pageIterator walks every page of a paginated hostd endpoint, in the style
+// of bufio.Scanner
+type pageIterator[T any] struct {
+	fetch    func(limit, offset int) ([]T, int, error)
+	pageSize int
+
+	page    []T
+	current T
+	count   contractCount
+	started bool
+	err     error
+}
+
+// newPageIterator returns an iterator calling fetch for pageSize items at a
+// time. fetch returns a page and the total number of items.
+func newPageIterator[T any](fetch func(limit, offset int) ([]T, int, error), pageSize int) *pageIterator[T] {
+	return &pageIterator[T]{
+		fetch:    fetch,
+		pageSize: pageSize,
+	}
+}
+
+// Next advances to the next item, fetching the next page when needed. It
+// returns false when every item has been read or a request failed.
+func (it *pageIterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if len(it.page) == 0 {
+		// the total is the number of items matching the filter, an
+		// empty page also stops the iteration in case it is wrong
+		if it.started && it.count.fetched >= it.count.total {
+			return false
+		}
+		it.page, it.count.total, it.err = it.fetch(it.pageSize, it.count.fetched)
+		it.started = true
+		if it.err != nil || len(it.page) == 0 {
+			return false
+		}
+	}
+	it.current, it.page = it.page[0], it.page[1:]
+	it.count.fetched++
+	return true
+}
+
+// Item returns the current item
+func (it *pageIterator[T]) Item() T {
+	return it.current
+}
+
+// Count returns the number of items reported by the API and read so far
+func (it *pageIterator[T]) Count() contractCount {
+	return it.count
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *pageIterator[T]) Err() error {
+	return it.err
+}
+
+// fetchV1Contracts returns every active v1 contract
+func fetchV1Contracts(client *api.Client, pageSize int) ([]contract, contractCount, error) {
+	it := newPageIterator(func(limit, offset int) ([]contracts.Contract, int, error) {
+		return client.Contracts(contracts.ContractFilter{
+			Statuses: []contracts.ContractStatus{contracts.ContractStatusActive},
+			Limit:    limit,
+			Offset:   offset,
+		})
+	}, pageSize)
+	return collectContracts(it, normalizeV1Contract)
+}
+
+// fetchV2Contracts returns every active or renewed v2 contract
+func fetchV2Contracts(client *api.Client, pageSize int) ([]contract, contractCount, error) {
+	it := newPageIterator(func(limit, offset int) ([]contracts.V2Contract, int, error) {
+		return client.V2Contracts(contracts.V2ContractFilter{
+			Statuses: []contracts.V2ContractStatus{
+				contracts.V2ContractStatusActive,
+				contracts.V2ContractStatusRenewed,
+			},
+			Limit:  limit,
+			Offset: offset,
+		})
+	}, pageSize)
+	return collectContracts(it, normalizeV2Contract)
+}
+
+// collectContracts reads every contract of it. The pages are requested by
+// offset, so a contract moving between two requests can be returned twice and
+// is only kept once; the fetched count is the number of distinct contracts.
+func collectContracts[T any](it *pageIterator[T], normalize func(T) contract) ([]contract, contractCount, error) {
+	var all []contract
+	seen := make(map[types.FileContractID]bool)
+	for it.Next() {
+		c := normalize(it.Item())
+		if seen[c.ID] {
+			continue
+		}
+		seen[c.ID] = true
+		all = append(all, c)
+	}
+	count := it.Count()
+	count.fetched = len(all)
+	return all, count, it.Err()
+}
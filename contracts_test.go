@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/v2/host/contracts"
+)
+
+// fakePages serves items by page like a paginated hostd endpoint and records
+// the offsets requested
+type fakePages[T any] struct {
+	items []T
+	// total is the number of items reported, which may be wrong
+	total int
+	// failAt makes the request at this offset fail, when set
+	failAt  int
+	offsets []int
+}
+
+func (s *fakePages[T]) fetch(limit, offset int) ([]T, int, error) {
+	s.offsets = append(s.offsets, offset)
+	if s.failAt > 0 && offset == s.failAt {
+		return nil, 0, errors.New("request failed")
+	}
+	if offset >= len(s.items) {
+		return nil, s.total, nil
+	}
+	end := min(offset+limit, len(s.items))
+	return s.items[offset:end], s.total, nil
+}
+
+func TestPageIterator(t *testing.T) {
+	tests := []struct {
+		name        string
+		items       int
+		total       int
+		failAt      int
+		wantOffsets []int
+		wantRead    int
+		wantErr     bool
+	}{
+		{name: "empty", items: 0, total: 0, wantOffsets: []int{0}},
+		{name: "single page", items: 2, total: 2, wantOffsets: []int{0}, wantRead: 2},
+		{name: "stops at total on a page boundary", items: 4, total: 4, wantOffsets: []int{0, 2}, wantRead: 4},
+		{name: "last page partial", items: 5, total: 5, wantOffsets: []int{0, 2, 4}, wantRead: 5},
+		{name: "total too high stops on empty page", items: 3, total: 10, wantOffsets: []int{0, 2, 3}, wantRead: 3},
+		{name: "total too low stops at total", items: 6, total: 4, wantOffsets: []int{0, 2}, wantRead: 4},
+		{name: "failed page", items: 6, total: 6, failAt: 2, wantOffsets: []int{0, 2}, wantRead: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &fakePages[int]{total: tt.total, failAt: tt.failAt}
+			for i := range tt.items {
+				src.items = append(src.items, i)
+			}
+
+			it := newPageIterator(src.fetch, 2)
+			var read []int
+			for it.Next() {
+				read = append(read, it.Item())
+			}
+			if !reflect.DeepEqual(src.offsets, tt.wantOffsets) {
+				t.Errorf("offsets = %v, want %v", src.offsets, tt.wantOffsets)
+			}
+			if len(read) != tt.wantRead {
+				t.Errorf("read %d items, want %d", len(read), tt.wantRead)
+			}
+			for i, v := range read {
+				if v != i {
+					t.Fatalf("item %d = %d, want %d", i, v, i)
+				}
+			}
+			if (it.Err() != nil) != tt.wantErr {
+				t.Errorf("Err() = %v, want error %v", it.Err(), tt.wantErr)
+			}
+			if count := it.Count(); count.fetched != tt.wantRead || count.total != tt.total && !tt.wantErr {
+				t.Errorf("Count() = %+v, want fetched %d of %d", count, tt.wantRead, tt.total)
+			}
+		})
+	}
+}
+
+func TestCollectContractsDeduplicates(t *testing.T) {
+	id := func(b byte) types.FileContractID { return types.FileContractID{b} }
+	// a contract moving between two requests is returned on both pages
+	pages := map[int][]contract{
+		0: {{ID: id(1)}, {ID: id(2)}},
+		2: {{ID: id(2)}, {ID: id(3)}},
+	}
+	it := newPageIterator(func(limit, offset int) ([]contract, int, error) {
+		return pages[offset], 4, nil
+	}, 2)
+
+	got, count, err := collectContracts(it, func(c contract) contract { return c })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []types.FileContractID
+	for _, c := range got {
+		ids = append(ids, c.ID)
+	}
+	if want := []types.FileContractID{id(1), id(2), id(3)}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("contracts = %v, want %v", ids, want)
+	}
+	if want := (contractCount{total: 4, fetched: 3}); count != want {
+		t.Errorf("count = %+v, want %+v", count, want)
+	}
+}
+
+func TestNormalizeContracts(t *testing.T) {
+	renewal := types.FileContractID{1}
+	v1 := func(status contracts.ContractStatus, renewedTo types.FileContractID) contracts.Contract {
+		var c contracts.Contract
+		c.Status = status
+		c.RenewedTo = renewedTo
+		c.Revision.UnlockConditions.PublicKeys = []types.UnlockKey{types.PublicKey{}.UnlockKey()}
+		c.Revision.ValidProofOutputs = make([]types.SiacoinOutput, 2)
+		c.Revision.MissedProofOutputs = make([]types.SiacoinOutput, 2)
+		return c
+	}
+	v2 := func(status contracts.V2ContractStatus, renewedTo types.FileContractID) contracts.V2Contract {
+		return contracts.V2Contract{Status: status, RenewedTo: renewedTo}
+	}
+
+	tests := []struct {
+		name        string
+		contract    contract
+		wantActive  bool
+		wantRenewed bool
+	}{
+		{"v1 pending", normalizeV1Contract(v1(contracts.ContractStatusPending, types.FileContractID{})), false, false},
+		{"v1 active", normalizeV1Contract(v1(contracts.ContractStatusActive, types.FileContractID{})), true, false},
+		// a renewed v1 contract stays active until its window ends
+		{"v1 active renewed", normalizeV1Contract(v1(contracts.ContractStatusActive, renewal)), true, true},
+		{"v1 successful", normalizeV1Contract(v1(contracts.ContractStatusSuccessful, types.FileContractID{})), false, false},
+		{"v1 failed", normalizeV1Contract(v1(contracts.ContractStatusFailed, types.FileContractID{})), false, false},
+		{"v2 pending", normalizeV2Contract(v2(contracts.V2ContractStatusPending, types.FileContractID{})), false, false},
+		{"v2 active", normalizeV2Contract(v2(contracts.V2ContractStatusActive, types.FileContractID{})), true, false},
+		{"v2 renewed", normalizeV2Contract(v2(contracts.V2ContractStatusRenewed, renewal)), false, true},
+		{"v2 successful", normalizeV2Contract(v2(contracts.V2ContractStatusSuccessful, types.FileContractID{})), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.contract.Active != tt.wantActive {
+				t.Errorf("Active = %v, want %v", tt.contract.Active, tt.wantActive)
+			}
+			if tt.contract.Renewed != tt.wantRenewed {
+				t.Errorf("Renewed = %v, want %v", tt.contract.Renewed, tt.wantRenewed)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"time"
+
+	"go.sia.tech/hostd/v2/api"
+	"go.sia.tech/hostd/v2/host/metrics"
+)
+
+// earnedRevenue holds the revenue realized on each of the last days, the
+// last day being today so far
+type earnedRevenue struct {
+	dayStart time.Time
+	days     []revenue
+}
+
+// revenueFromMetrics converts the revenue reported by hostd, registry reads
+// and writes being counted as RPC like for the contracts
+func revenueFromMetrics(r metrics.Revenue) revenue {
+	return revenue{
+		Storage: convertCurrency(r.Storage),
+		Ingress: convertCurrency(r.Ingress),
+		Egress:  convertCurrency(r.Egress),
+		RPC:     convertCurrency(r.RPC) + convertCurrency(r.RegistryRead) + convertCurrency(r.RegistryWrite),
+	}
+}
+
+// fetchEarnedRevenue reads the cumulative earned revenue at the start of each
+// of the last days days from the hostd period metrics and derives the revenue
+// of each day. Today is measured up to current, the metrics of now.
+func fetchEarnedRevenue(client *api.Client, current metrics.Metrics, now time.Time, days int, loc *time.Location) (*earnedRevenue, error) {
+	now = now.In(loc)
+	year, month, day := now.Date()
+	start := time.Date(year, month, day-(days-1), 0, 0, 0, 0, loc)
+
+	periods, err := client.PeriodMetrics(start, days, metrics.IntervalDaily)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &earnedRevenue{dayStart: start, days: make([]revenue, len(periods))}
+	for i, m := range periods {
+		end := current
+		if i+1 < len(periods) {
+			end = periods[i+1]
+		}
+		e.days[i] = revenueFromMetrics(end.Revenue.Earned).sub(revenueFromMetrics(m.Revenue.Earned))
+	}
+	return e, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// expirationWindows are the values of the window label of the expiration
+// metrics, counted from the current height
+var expirationWindows = []struct {
+	label    string
+	duration time.Duration
+}{
+	{"1d", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+var (
+	hostdContractsExpiring            = newDesc("hostd_contracts_expiring", "Number of active contracts expiring within the window", "window")
+	hostdContractsExpiringBytes       = newDesc("hostd_contracts_expiring_bytes", "Amount of data of the active contracts expiring within the window in bytes", "window")
+	hostdContractsEnteringProofWindow = newDesc("hostd_contracts_entering_proof_window", "Number of active contracts entering their proof window within the window", "window")
+	hostdContractsEnteringProofBytes  = newDesc("hostd_contracts_entering_proof_window_bytes", "Amount of data of the active contracts entering their proof window within the window in bytes", "window")
+	hostdContractsOverdue             = newDesc("hostd_contracts_overdue", "Number of active contracts past their expiration height and not resolved yet")
+	hostdContractsOverdueBytes        = newDesc("hostd_contracts_overdue_bytes", "Amount of data of the active contracts past their expiration height in bytes")
+)
+
+// churnBucket counts the contracts and data of one window
+type churnBucket struct {
+	contracts int
+	bytes     uint64
+}
+
+// contractChurn holds the active contracts expiring or entering their proof
+// window within each of expirationWindows
+type contractChurn struct {
+	expiring      []churnBucket
+	enteringProof []churnBucket
+	// overdue holds the contracts past their expiration, waiting for hostd
+	// to resolve them
+	overdue churnBucket
+}
+
+// newContractChurn buckets the active contracts by the number of blocks left
+// until their proof window and expiration. The windows are converted to
+// blocks with the observed block interval.
+func newContractChurn(contratos []contract, tip uint64, interval time.Duration) *contractChurn {
+	churn := &contractChurn{
+		expiring:      make([]churnBucket, len(expirationWindows)),
+		enteringProof: make([]churnBucket, len(expirationWindows)),
+	}
+	for _, c := range contratos {
+		// renewed contracts handed their data over to their successor, a
+		// renewed v1 contract stays active until its window ends
+		if !c.Active || c.Renewed {
+			continue
+		}
+		if c.ExpirationHeight <= tip {
+			churn.overdue.contracts++
+			churn.overdue.bytes += c.Filesize
+			continue
+		}
+		for i, w := range expirationWindows {
+			limit := tip + uint64((w.duration+interval-1)/interval)
+			if c.ExpirationHeight <= limit {
+				churn.expiring[i].contracts++
+				churn.expiring[i].bytes += c.Filesize
+			}
+			if c.ProofHeight > tip && c.ProofHeight <= limit {
+				churn.enteringProof[i].contracts++
+				churn.enteringProof[i].bytes += c.Filesize
+			}
+		}
+	}
+	return churn
+}
+
+// collectContractChurn exports the upcoming expirations and proof windows
+func collectContractChurn(ch chan<- prometheus.Metric, churn *contractChurn) {
+	for i, w := range expirationWindows {
+		ch <- prometheus.MustNewConstMetric(hostdContractsExpiring, prometheus.GaugeValue, float64(churn.expiring[i].contracts), w.label)
+		ch <- prometheus.MustNewConstMetric(hostdContractsExpiringBytes, prometheus.GaugeValue, float64(churn.expiring[i].bytes), w.label)
+		ch <- prometheus.MustNewConstMetric(hostdContractsEnteringProofWindow, prometheus.GaugeValue, float64(churn.enteringProof[i].contracts), w.label)
+		ch <- prometheus.MustNewConstMetric(hostdContractsEnteringProofBytes, prometheus.GaugeValue, float64(churn.enteringProof[i].bytes), w.label)
+	}
+	ch <- prometheus.MustNewConstMetric(hostdContractsOverdue, prometheus.GaugeValue, float64(churn.overdue.contracts))
+	ch <- prometheus.MustNewConstMetric(hostdContractsOverdueBytes, prometheus.GaugeValue, float64(churn.overdue.bytes))
+}
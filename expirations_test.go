@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewContractChurn(t *testing.T) {
+	// with one block per hour, the windows are 24, 168 and 720 blocks
+	const tip = 1000
+	tests := []struct {
+		name     string
+		contract contract
+		// wantExpiring and wantEntering are the contracts counted in each
+		// of expirationWindows
+		wantExpiring []int
+		wantEntering []int
+		wantOverdue  int
+	}{
+		{"expired at tip", contract{Active: true, ProofHeight: 900, ExpirationHeight: tip}, []int{0, 0, 0}, []int{0, 0, 0}, 1},
+		{"expired before tip", contract{Active: true, ProofHeight: 800, ExpirationHeight: 900}, []int{0, 0, 0}, []int{0, 0, 0}, 1},
+		{"expiring next block", contract{Active: true, ProofHeight: 900, ExpirationHeight: tip + 1}, []int{1, 1, 1}, []int{0, 0, 0}, 0},
+		{"expiring at the end of the day", contract{Active: true, ProofHeight: tip, ExpirationHeight: tip + 24}, []int{1, 1, 1}, []int{0, 0, 0}, 0},
+		{"entering proof next block", contract{Active: true, ProofHeight: tip + 1, ExpirationHeight: tip + 145}, []int{0, 1, 1}, []int{1, 1, 1}, 0},
+		{"expiring after the day", contract{Active: true, ProofHeight: tip + 25, ExpirationHeight: tip + 169}, []int{0, 0, 1}, []int{0, 1, 1}, 0},
+		{"beyond every window", contract{Active: true, ProofHeight: tip + 721, ExpirationHeight: tip + 865}, []int{0, 0, 0}, []int{0, 0, 0}, 0},
+		{"not active", contract{ProofHeight: 900, ExpirationHeight: tip}, []int{0, 0, 0}, []int{0, 0, 0}, 0},
+		{"renewed", contract{Active: true, Renewed: true, ProofHeight: tip + 1, ExpirationHeight: tip + 24}, []int{0, 0, 0}, []int{0, 0, 0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.contract.Filesize = 100
+			churn := newContractChurn([]contract{tt.contract}, tip, time.Hour)
+			for i, w := range expirationWindows {
+				if got := churn.expiring[i]; got != (churnBucket{tt.wantExpiring[i], uint64(100 * tt.wantExpiring[i])}) {
+					t.Errorf("expiring %s = %+v, want %d contracts", w.label, got, tt.wantExpiring[i])
+				}
+				if got := churn.enteringProof[i]; got != (churnBucket{tt.wantEntering[i], uint64(100 * tt.wantEntering[i])}) {
+					t.Errorf("entering proof %s = %+v, want %d contracts", w.label, got, tt.wantEntering[i])
+				}
+			}
+			if got := churn.overdue; got != (churnBucket{tt.wantOverdue, uint64(100 * tt.wantOverdue)}) {
+				t.Errorf("overdue = %+v, want %d contracts", got, tt.wantOverdue)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	configReloadSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "hostd_exporter_config_last_reload_successful", Help: "Whether the last configuration reload succeeded"})
+	configReloadSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "hostd_exporter_config_last_reload_success_timestamp_seconds", Help: "Unix time of the last successful configuration reload"})
+)
+
+func init() {
+	prometheus.MustRegister(configReloadSuccess, configReloadSeconds)
+}
+
+// monitor polls one target until it is stopped
+type monitor struct {
+	target     target
+	collector  *HostdCollector
+	registerer prometheus.Registerer
+	stop       chan struct{}
+}
+
+// exporter runs a monitor for every configured target and replaces them when
+// the configuration is reloaded, without touching the HTTP listener
+type exporter struct {
+	configFile string
+	passwd     string
+
+	mu       sync.Mutex
+	cfg      *config
+	monitors []*monitor
+	// collectors are kept across reloads, so that the blocks and events
+	// observed by the previous polls of a hostd are not lost
+	collectors map[collectorKey]*HostdCollector
+	// probes are the collectors of the hosts only polled through /probe
+	probes map[collectorKey]*probedHost
+}
+
+// probedHost is a collector kept between two probes of the same hostd
+type probedHost struct {
+	collector *HostdCollector
+	lastProbe time.Time
+}
+
+// probeExpiry is how long the collector of a host is kept after its last
+// probe, well above any sensible scrape interval
+const probeExpiry = time.Hour
+
+// config returns the configuration currently applied
+func (e *exporter) config() *config {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.cfg
+}
+
+// apply replaces the running monitors with the targets of cfg. The previous
+// monitors are left running if the new ones cannot be created or registered.
+func (e *exporter) apply(cfg *config) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	collectors := make(map[collectorKey]*HostdCollector, len(cfg.Targets))
+	monitors := make([]*monitor, 0, len(cfg.Targets))
+	for _, t := range cfg.Targets {
+		key, err := t.collectorKey()
+		if err != nil {
+			return err
+		}
+		collector, ok := collectors[key]
+		if !ok {
+			if collector, ok = e.collectors[key]; !ok {
+				collector = key.newCollector(cfg)
+			}
+			collectors[key] = collector
+		}
+		monitors = append(monitors, &monitor{
+			target:    t,
+			collector: collector,
+			// every metric of the target carries its name in the host label
+			registerer: prometheus.WrapRegistererWith(t.labels(), prometheus.DefaultRegisterer),
+			stop:       make(chan struct{}),
+		})
+	}
+
+	// a target kept by the new configuration exports the same metrics, so
+	// the previous collectors are unregistered before the new ones are
+	// registered, and registered again if one of the new ones is rejected
+	for _, m := range e.monitors {
+		m.registerer.Unregister(m.collector)
+	}
+	for i, m := range monitors {
+		if err := m.registerer.Register(m.collector); err != nil {
+			for _, registered := range monitors[:i] {
+				registered.registerer.Unregister(registered.collector)
+			}
+			for _, old := range e.monitors {
+				old.registerer.MustRegister(old.collector)
+			}
+			return fmt.Errorf("target %q: %w", m.target.Name, err)
+		}
+	}
+
+	for _, m := range e.monitors {
+		close(m.stop)
+	}
+	for _, collector := range collectors {
+		collector.setConfig(cfg)
+	}
+	for _, m := range monitors {
+		// each target is polled on its own so a slow or broken hostd
+		// does not delay the others
+		go func() {
+			updateMetrics(m.target, m.collector)
+			startMonitor(cfg.Refresh, m.target, m.collector, m.stop)
+		}()
+	}
+	e.cfg = cfg
+	e.monitors = monitors
+	e.collectors = collectors
+	return nil
+}
+
+// probeCollector returns the collector polling the hostd of key for /probe.
+// The collector of a target is used if there is one, otherwise one is kept
+// per host so that the wallet events and blocks are not read again from the
+// beginning on every probe.
+func (e *exporter) probeCollector(key collectorKey) *HostdCollector {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if collector, ok := e.collectors[key]; ok {
+		return collector
+	}
+
+	now := time.Now()
+	for k, p := range e.probes {
+		if now.Sub(p.lastProbe) > probeExpiry {
+			delete(e.probes, k)
+		}
+	}
+	if e.probes == nil {
+		e.probes = make(map[collectorKey]*probedHost)
+	}
+	p, ok := e.probes[key]
+	if !ok {
+		p = &probedHost{collector: key.newCollector(e.cfg)}
+		e.probes[key] = p
+	}
+	p.lastProbe = now
+	p.collector.setConfig(e.cfg)
+	return p.collector
+}
+
+// reload reads the configuration file again and applies it. A configuration
+// that fails to load or validate leaves the current one in place.
+func (e *exporter) reload() error {
+	if e.configFile == "" {
+		return errors.New("no configuration file to reload, start the exporter with -config.file")
+	}
+	cfg, err := loadConfig(e.configFile, e.passwd)
+	if err == nil {
+		err = e.apply(cfg)
+	}
+	if err != nil {
+		configReloadSuccess.Set(0)
+		return err
+	}
+	configReloadSuccess.Set(1)
+	configReloadSeconds.SetToCurrentTime()
+	return nil
+}
+
+// Gather implements prometheus.Gatherer, filtering the default registry with
+// the metric filter of the current configuration
+func (e *exporter) Gather() ([]*dto.MetricFamily, error) {
+	return filteringGatherer{gatherer: prometheus.DefaultGatherer, filter: e.config().Metrics}.Gather()
+}
+
+// reloadHandler reloads the configuration on POST /-/reload
+func reloadHandler(e *exporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := e.reload(); err != nil {
+			log.Println("failed to reload configuration:", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Println("configuration reloaded")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"time"
+)
+
+// revenueForecast holds the potential revenue of the active contracts,
+// bucketed by the day, week and month they expire in. Days cover the
+// configured horizon, weeks and months cover every contract.
+type revenueForecast struct {
+	dayStart time.Time
+	days     []revenue
+
+	// weeks start on monday
+	weekStart time.Time
+	weeks     []revenue
+
+	monthStart time.Time
+	months     []revenue
+}
+
+// addBucket adds value to buckets[i], growing buckets as needed. Contracts
+// already past their expiration are counted in the first bucket.
+func addBucket(buckets []revenue, i int, value revenue) []revenue {
+	if i < 0 {
+		i = 0
+	}
+	for len(buckets) <= i {
+		buckets = append(buckets, revenue{})
+	}
+	buckets[i] = buckets[i].add(value)
+	return buckets
+}
+
+// daysBetween returns the number of calendar days from the date of a to the
+// date of b. Days are counted on dates rather than durations so a day
+// lasting 23 or 25 hours because of daylight saving is still one day.
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	from := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	to := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// newRevenueForecast buckets the contracts by the estimated time of their
+// expiration. Days, weeks and months follow the calendar of loc and the daily
+// buckets cover the next days days.
+func newRevenueForecast(contratos []contract, clock blockClock, now time.Time, days int, loc *time.Location) *revenueForecast {
+	now = now.In(loc)
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	f := &revenueForecast{
+		dayStart: today,
+		days:     make([]revenue, days),
+		// time.Weekday starts on sunday
+		weekStart:  today.AddDate(0, 0, -(int(today.Weekday())+6)%7),
+		monthStart: time.Date(year, month, 1, 0, 0, 0, 0, loc),
+		// the current, next and following months are always exported
+		months: make([]revenue, 3),
+	}
+
+	for _, c := range contratos {
+		expiration := clock.time(c.ExpirationHeight).In(loc)
+
+		if day := daysBetween(today, expiration); day < days {
+			f.days = addBucket(f.days, day, c.Revenue)
+		}
+		f.weeks = addBucket(f.weeks, daysBetween(f.weekStart, expiration)/7, c.Revenue)
+		y, m, _ := expiration.Date()
+		f.months = addBucket(f.months, (y*12+int(m))-(year*12+int(month)), c.Revenue)
+	}
+	return f
+}
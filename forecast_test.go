@@ -0,0 +1,121 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDaysBetween(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, madrid)
+	}
+
+	tests := []struct {
+		name string
+		a, b time.Time
+		want int
+	}{
+		{"same day", date(2024, 3, 28, 0, 0), date(2024, 3, 28, 23, 59), 0},
+		{"next day", date(2024, 3, 28, 23, 59), date(2024, 3, 29, 0, 0), 1},
+		{"23 hour day", date(2024, 3, 31, 0, 0), date(2024, 4, 1, 0, 0), 1},
+		{"across spring forward", date(2024, 3, 28, 0, 0), date(2024, 4, 1, 0, 0), 4},
+		{"25 hour day", date(2024, 10, 27, 0, 0), date(2024, 10, 28, 0, 0), 1},
+		{"across fall back", date(2024, 10, 26, 0, 0), date(2024, 10, 27, 23, 30), 1},
+		{"leap february", date(2024, 1, 31, 12, 0), date(2024, 3, 1, 12, 0), 30},
+		{"backwards", date(2024, 3, 29, 0, 0), date(2024, 3, 28, 12, 0), -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := daysBetween(tt.a, tt.b); got != tt.want {
+				t.Errorf("daysBetween(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRevenueForecastBuckets(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, madrid)
+	}
+
+	// with one block per hour, a contract expires expiration hours after now
+	const tip = 1000
+	tests := []struct {
+		name       string
+		now        time.Time
+		expiration int
+		// wantDay is -1 when the contract is beyond the daily horizon
+		wantDay   int
+		wantWeek  int
+		wantMonth int
+	}{
+		{"expiring now", date(2024, 3, 28, 12, 0), 0, 0, 0, 0},
+		{"already expired", date(2024, 3, 28, 12, 0), -100, 0, 0, 0},
+		// 83 hours is 3.5 days, but the 23 hour day of the DST change
+		// makes it the start of the fourth day
+		{"after spring forward", date(2024, 3, 28, 12, 0), 83, 4, 1, 1},
+		// 36 hours after 12:30 is 23:30 of the next day thanks to the 25
+		// hour day, not the day after
+		{"after fall back", date(2024, 10, 26, 12, 30), 36, 1, 0, 0},
+		{"beyond the daily horizon", date(2024, 3, 28, 12, 0), 40 * 24, -1, 6, 2},
+		{"week starts on monday", date(2024, 4, 7, 12, 0), 24, 1, 1, 0},
+		{"next year", date(2024, 12, 30, 12, 0), 3 * 24, 3, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := blockClock{tip: tip, tipTime: tt.now, interval: time.Hour}
+			contratos := []contract{{
+				ExpirationHeight: uint64(tip + tt.expiration),
+				Revenue:          revenue{Storage: 1, RPC: 2},
+			}}
+			f := newRevenueForecast(contratos, clock, tt.now.UTC(), 7, madrid)
+
+			if len(f.days) != 7 {
+				t.Fatalf("%d daily buckets, want 7", len(f.days))
+			}
+			if len(f.months) < 3 {
+				t.Fatalf("%d monthly buckets, want at least 3", len(f.months))
+			}
+			checkBucket(t, "day", f.days, tt.wantDay)
+			checkBucket(t, "week", f.weeks, tt.wantWeek)
+			checkBucket(t, "month", f.months, tt.wantMonth)
+
+			year, month, day := tt.now.Date()
+			if want := time.Date(year, month, day, 0, 0, 0, 0, madrid); !f.dayStart.Equal(want) {
+				t.Errorf("dayStart = %v, want %v", f.dayStart, want)
+			}
+			if f.weekStart.Weekday() != time.Monday || f.weekStart.After(f.dayStart) || f.dayStart.Sub(f.weekStart) >= 7*24*time.Hour {
+				t.Errorf("weekStart = %v, want the monday of %v", f.weekStart, f.dayStart)
+			}
+			if want := time.Date(year, month, 1, 0, 0, 0, 0, madrid); !f.monthStart.Equal(want) {
+				t.Errorf("monthStart = %v, want %v", f.monthStart, want)
+			}
+		})
+	}
+}
+
+// checkBucket checks that only buckets[want] holds the revenue of the
+// contract, or no bucket when want is -1
+func checkBucket(t *testing.T, kind string, buckets []revenue, want int) {
+	t.Helper()
+	for i, r := range buckets {
+		expected := revenue{}
+		if i == want {
+			expected = revenue{Storage: 1, RPC: 2}
+		}
+		if r != expected {
+			t.Errorf("%s %d = %+v, want %+v", kind, i, r, expected)
+		}
+	}
+	if want >= len(buckets) {
+		t.Errorf("%d %s buckets, want the contract in bucket %d", len(buckets), kind, want)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/v2/host/metrics"
+)
+
+// hostMetricField is a numeric field of metrics.Metrics exported as a gauge
+type hostMetricField struct {
+	desc     *prometheus.Desc
+	index    []int
+	currency bool
+}
+
+var currencyType = reflect.TypeOf(types.Currency{})
+
+// hostMetricFields holds every numeric field of metrics.Metrics. They are
+// read from the struct so that fields added to hostd are exported without
+// changes to the exporter.
+var hostMetricFields = walkHostMetrics(reflect.TypeOf(metrics.Metrics{}), "hostd_metrics", "", nil)
+
+// walkHostMetrics returns the numeric fields of t, named after prefix and the
+// JSON names of the fields leading to them
+func walkHostMetrics(t reflect.Type, prefix string, path string, index []int) (fields []hostMetricField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		} else if name == "" {
+			name = f.Name
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		fieldPrefix, fieldPath := prefix+"_"+snakeCase(name), path+"."+name
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			// embedded structs are flattened like encoding/json does
+			fieldPrefix, fieldPath = prefix, path
+		}
+
+		switch {
+		case f.Type == currencyType:
+			fields = append(fields, hostMetricField{
+				desc:     newDesc(fieldPrefix, "Value of "+fieldPath[1:]+" in SC returned by the hostd metrics endpoint"),
+				index:    fieldIndex,
+				currency: true,
+			})
+		case f.Type.Kind() == reflect.Struct:
+			fields = append(fields, walkHostMetrics(f.Type, fieldPrefix, fieldPath, fieldIndex)...)
+		case isNumeric(f.Type.Kind()):
+			fields = append(fields, hostMetricField{
+				desc:  newDesc(fieldPrefix, "Value of "+fieldPath[1:]+" returned by the hostd metrics endpoint"),
+				index: fieldIndex,
+			})
+		}
+	}
+	return fields
+}
+
+// isNumeric reports whether values of kind can be exported as a float64
+func isNumeric(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// snakeCase converts a camelCase JSON name to snake_case, keeping acronyms
+// together: baseRPCPrice becomes base_rpc_price
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// collectHostMetricFields exports every numeric field of the hostd metrics
+func collectHostMetricFields(gauge func(*prometheus.Desc, float64), m *metrics.Metrics) {
+	v := reflect.ValueOf(m).Elem()
+	for _, f := range hostMetricFields {
+		field := v.FieldByIndex(f.index)
+		switch {
+		case f.currency:
+			gauge(f.desc, convertCurrency(field.Interface().(types.Currency)))
+		case field.Kind() == reflect.Bool:
+			gauge(f.desc, boolToFloat64(field.Bool()))
+		case field.CanInt():
+			gauge(f.desc, float64(field.Int()))
+		case field.CanUint():
+			gauge(f.desc, float64(field.Uint()))
+		default:
+			gauge(f.desc, field.Float())
+		}
+	}
+}
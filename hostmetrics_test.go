@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"rhp", "rhp"},
+		{"RHP", "rhp"},
+		{"registryRead", "registry_read"},
+		{"lockedCollateral", "locked_collateral"},
+		{"baseRPCPrice", "base_rpc_price"},
+		{"contractID", "contract_id"},
+		{"v2Contracts", "v2_contracts"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := snakeCase(tt.in); got != tt.want {
+			t.Errorf("snakeCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
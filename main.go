@@ -3,9 +3,12 @@ package main
 import (
 	"flag"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 )
 
@@ -18,26 +21,58 @@ func boolToFloat64(b bool) float64 {
 }
 
 // startMonitor refreshes the Sia metrics periodically as defined by refreshRate
-func startMonitor(refreshRate time.Duration, passwd string, address string) {
-	for range time.Tick(time.Minute * refreshRate) {
-		updateMetrics(passwd, address)
+// until stop is closed
+func startMonitor(refreshRate time.Duration, t target, collector *HostdCollector, stop <-chan struct{}) {
+	ticker := time.NewTicker(refreshRate)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			updateMetrics(t, collector)
+		}
 	}
 }
 
 // updateMetrics calls the various metric collection functions
-func updateMetrics(passwd string, address string) {
+func updateMetrics(t target, collector *HostdCollector) {
 	//do something every timeRefresh
 
 	//call collector's function for curl values
-	callClient(passwd, address)
+	if err := collector.Update(); err != nil {
+		log.Printf("failed to poll hostd %q: %v", t.Name, err)
+	}
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		if err := runBackfill(os.Args[2:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
 	// TEST VARIABLES
 	port := flag.Int("port", 8101, "Port to serve Prometheus Metrics on")
+	configFile := flag.String("config.file", "", "Configuration file, overrides every flag but -port and -passwd")
 	refresh := flag.Int("refresh", 1, "Frequency to get Metrics from Hostd (minutes)")
+	timeout := flag.Duration("timeout", 0, "Maximum duration of a poll of the hostd API (default the refresh interval)")
 	passwd := flag.String("passwd", "Sia is Awesome", "Hostd API password")
 	address := flag.String("address", "127.0.0.1:9980", "Hostd API address")
+	forecastDays := flag.Int("forecast.days", 90, "Number of days covered by the daily revenue forecast")
+	earnedDays := flag.Int("earned.days", 30, "Number of past days covered by the realized daily revenue, today included")
+	timezone := flag.String("timezone", "", "Timezone of the revenue forecast calendar, as an IANA name like Europe/Madrid (default local time)")
+	lookback := flag.Duration("blocktime.lookback", 48*time.Hour, "How far back the blocks observed since the exporter started are used to estimate the time between blocks")
+	v2Only := flag.Bool("contracts.v2-only", false, "Leave the legacy v1 contracts out of the revenue forecasts")
+	renterTopN := flag.Int("renters.top", 10, "Number of renters exported on their own, the others are summed as \"other\"")
+	statVolumes := flag.Bool("volumes.statfs", false, "Compare the volumes with the local filesystem, for hosts running on the same machine as the exporter")
+	pageSize := flag.Int("contracts.page-size", 500, "Number of contracts requested at a time from the hostd API")
+	var targetAddresses, targetPasswdFiles keyValueFlag
+	flag.Var(&targetAddresses, "target", "Hostd to monitor as name=address, can be repeated (overrides -address)")
+	flag.Var(&targetPasswdFiles, "target.passwd-file", "File holding the API password of a target as name=path, can be repeated (default -passwd)")
+	var modulePasswdFiles keyValueFlag
+	flag.Var(&modulePasswdFiles, "module", "Credentials module enabling /probe as name=path to a password file, can be repeated (/probe is disabled without any)")
 
 	flag.Parse()
 
@@ -46,15 +81,63 @@ func main() {
 		*passwd = passwdEnv
 	}
 
-	// Set the metrics initially before starting the monitor and HTTP server
-	// If you don't do this all the metrics start with a "0" until they are set
-	updateMetrics(*passwd, *address)
+	var cfg *config
+	if *configFile != "" {
+		var err error
+		cfg, err = loadConfig(*configFile, *passwd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	} else {
+		targets, err := parseTargets(&targetAddresses, &targetPasswdFiles, *address, *passwd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for i := range targets {
+			targets[i].StatVolumes = *statVolumes
+		}
+		cfg = &config{
+			Refresh:           time.Duration(*refresh) * time.Minute,
+			Timeout:           *timeout,
+			ForecastDays:      *forecastDays,
+			EarnedDays:        *earnedDays,
+			ContractsPageSize: *pageSize,
+			V2ContractsOnly:   *v2Only,
+			RenterTopN:        *renterTopN,
+			Timezone:          *timezone,
+			BlockTimeLookback: *lookback,
+			Targets:           targets,
+			Modules:           parseModules(&modulePasswdFiles),
+		}
+		if err := cfg.validate(*passwd); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	e := &exporter{configFile: *configFile, passwd: *passwd}
+	if err := e.apply(cfg); err != nil {
+		log.Fatalln(err)
+	}
+	configReloadSuccess.Set(1)
+	configReloadSeconds.SetToCurrentTime()
 
-	// start the metrics collector
-	go startMonitor(time.Duration(*refresh), *passwd, *address)
+	// reload the configuration on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := e.reload(); err != nil {
+				log.Println("failed to reload configuration:", err)
+			} else {
+				log.Println("configuration reloaded")
+			}
+		}
+	}()
 
 	// This section will start the HTTP server and expose
 	// any metrics on the /metrics endpoint.
-	http.Handle("/metrics", promhttp.Handler())
+	http.Handle("/metrics", promhttp.HandlerFor(e, promhttp.HandlerOpts{}))
+	http.Handle("/probe", probeHandler(e))
+	http.Handle("/-/reload", reloadHandler(e))
 	http.ListenAndServe(":"+strconv.Itoa(*port), nil)
 }
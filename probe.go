@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// defaultModule is the credentials module used when a probe does not name one
+const defaultModule = "default"
+
+// scrapeTimeoutOffset is left out of the scrape timeout sent by Prometheus so
+// the probe answers before the scrape is abandoned, as in the blackbox_exporter
+const scrapeTimeoutOffset = 500 * time.Millisecond
+
+// module holds the credentials used to probe a hostd
+type module struct {
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"`
+}
+
+// parseModules builds the credentials modules from the -module flag
+func parseModules(passwdFiles *keyValueFlag) map[string]module {
+	modules := make(map[string]module)
+	for _, name := range passwdFiles.keys {
+		modules[name] = module{PasswordFile: passwdFiles.values[name]}
+	}
+	return modules
+}
+
+// probeTimeout returns the time a probe may spend polling the hostd, from the
+// X-Prometheus-Scrape-Timeout-Seconds header set by Prometheus. It is 0, the
+// configured timeout, when the header is missing.
+func probeTimeout(r *http.Request) (time.Duration, error) {
+	header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if header == "" {
+		return 0, nil
+	}
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("invalid scrape timeout %q", header)
+	}
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > scrapeTimeoutOffset {
+		timeout -= scrapeTimeoutOffset
+	}
+	return timeout, nil
+}
+
+// probeHandler serves the metrics of the hostd given by the target query
+// parameter, authenticated with the credentials of the module parameter,
+// in the style of the blackbox_exporter /probe endpoint. The credentials are
+// sent to whatever address is requested, so probing is disabled until a
+// module is configured.
+func probeHandler(e *exporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := e.config()
+		if len(cfg.Modules) == 0 {
+			http.Error(w, "probing is disabled, no module is configured", http.StatusNotFound)
+			return
+		}
+
+		timeout, err := probeTimeout(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		address := r.URL.Query().Get("target")
+		if address == "" {
+			http.Error(w, "target parameter is missing", http.StatusBadRequest)
+			return
+		}
+		moduleName := r.URL.Query().Get("module")
+		if moduleName == "" {
+			moduleName = defaultModule
+		}
+		m, ok := cfg.Modules[moduleName]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown module %q", moduleName), http.StatusBadRequest)
+			return
+		}
+
+		t := target{
+			Name:         address,
+			Address:      address,
+			Password:     m.Password,
+			PasswordFile: m.PasswordFile,
+		}
+		key, err := t.collectorKey()
+		if err != nil {
+			log.Printf("failed to probe hostd %q: %v", t.Name, err)
+			http.Error(w, "failed to load module credentials", http.StatusInternalServerError)
+			return
+		}
+		collector := e.probeCollector(key)
+		if err := collector.UpdateWithin(timeout); err != nil {
+			log.Printf("failed to probe hostd %q: %v", t.Name, err)
+		}
+
+		registry := prometheus.NewRegistry()
+		prometheus.WrapRegistererWith(t.labels(), registry).MustRegister(collector)
+		gatherer := filteringGatherer{gatherer: registry, filter: cfg.Metrics}
+		promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	}
+}
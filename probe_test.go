@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProbeTimeout(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{header: "", want: 0},
+		{header: "10", want: 9500 * time.Millisecond},
+		{header: "2.5", want: 2 * time.Second},
+		{header: "0.4", want: 400 * time.Millisecond},
+		{header: "0", wantErr: true},
+		{header: "-1", wantErr: true},
+		{header: "ten", wantErr: true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/probe", nil)
+		if tt.header != "" {
+			r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", tt.header)
+		}
+		got, err := probeTimeout(r)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("probeTimeout(%q) error = %v, want error %v", tt.header, err, tt.wantErr)
+		} else if got != tt.want {
+			t.Errorf("probeTimeout(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
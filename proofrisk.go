@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	hostdContractsInProofWindow    = newDesc("hostd_contracts_in_proof_window", "Number of active contracts inside their proof window without a confirmed storage proof")
+	hostdProofValueAtRisk          = newDesc("hostd_contracts_in_proof_window_value_at_risk", "Collateral and revenue lost if the contracts inside their proof window miss their storage proof, in SC")
+	hostdProofWindowMinRemaining   = newDesc("hostd_contracts_proof_window_min_blocks_remaining", "Number of blocks before the first proof window of the contracts without a storage proof closes")
+	hostdContractProofWindowBlocks = newDesc("hostd_contract_proof_window_blocks_remaining", "Number of blocks before the proof window of the contract closes, for the contracts without a storage proof", "contract_id")
+)
+
+// proofWindowContract is a contract inside its proof window
+type proofWindowContract struct {
+	id              string
+	blocksRemaining uint64
+	value           float64
+}
+
+// contractsInProofWindow returns the active contracts whose proof window is
+// open at tip. A contract stays active until its storage proof is
+// confirmed, so these are the contracts still waiting for a proof. A renewed
+// contract has no proof to submit, its data moved to its successor.
+func contractsInProofWindow(contratos []contract, tip uint64) []proofWindowContract {
+	res := []proofWindowContract{}
+	for _, c := range contratos {
+		if !c.Active || c.Renewed || tip < c.ProofHeight || tip >= c.ExpirationHeight {
+			continue
+		}
+		res = append(res, proofWindowContract{
+			id:              c.ID.String(),
+			blocksRemaining: c.ExpirationHeight - tip,
+			value:           c.ProofValue,
+		})
+	}
+	return res
+}
+
+// collectProofRisk exports the contracts waiting for a storage proof
+func collectProofRisk(ch chan<- prometheus.Metric, contratos []proofWindowContract) {
+	var value float64
+	var minRemaining uint64
+	for i, c := range contratos {
+		value += c.value
+		if i == 0 || c.blocksRemaining < minRemaining {
+			minRemaining = c.blocksRemaining
+		}
+		ch <- prometheus.MustNewConstMetric(hostdContractProofWindowBlocks, prometheus.GaugeValue, float64(c.blocksRemaining), c.id)
+	}
+	ch <- prometheus.MustNewConstMetric(hostdContractsInProofWindow, prometheus.GaugeValue, float64(len(contratos)))
+	ch <- prometheus.MustNewConstMetric(hostdProofValueAtRisk, prometheus.GaugeValue, value)
+	// without any contract in its proof window there is no deadline
+	if len(contratos) > 0 {
+		ch <- prometheus.MustNewConstMetric(hostdProofWindowMinRemaining, prometheus.GaugeValue, float64(minRemaining))
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"go.sia.tech/core/types"
+)
+
+func TestContractsInProofWindow(t *testing.T) {
+	// the proof window of the contract spans the heights [100, 144)
+	window := func(active, renewed bool) contract {
+		return contract{
+			ID:               types.FileContractID{1},
+			Active:           active,
+			Renewed:          renewed,
+			ProofHeight:      100,
+			ExpirationHeight: 144,
+			ProofValue:       12.5,
+		}
+	}
+	tests := []struct {
+		name     string
+		contract contract
+		tip      uint64
+		// wantRemaining is the number of blocks left in the window, 0 when
+		// the contract is not waiting for a proof
+		wantRemaining uint64
+	}{
+		{"before the window", window(true, false), 99, 0},
+		{"window opens", window(true, false), 100, 44},
+		{"inside the window", window(true, false), 120, 24},
+		{"last block of the window", window(true, false), 143, 1},
+		{"window closed", window(true, false), 144, 0},
+		{"after the window", window(true, false), 200, 0},
+		{"proof confirmed", window(false, false), 120, 0},
+		// a renewed v1 contract is still reported as active
+		{"renewed v1", window(true, true), 120, 0},
+		{"renewed v2", window(false, true), 120, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := []proofWindowContract{}
+			if tt.wantRemaining > 0 {
+				want = append(want, proofWindowContract{id: tt.contract.ID.String(), blocksRemaining: tt.wantRemaining, value: 12.5})
+			}
+			if got := contractsInProofWindow([]contract{tt.contract}, tt.tip); !reflect.DeepEqual(got, want) {
+				t.Errorf("contractsInProofWindow = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestCollectProofRisk(t *testing.T) {
+	tests := []struct {
+		name         string
+		contratos    []proofWindowContract
+		wantCount    float64
+		wantValue    float64
+		wantDeadline bool
+		wantMin      float64
+	}{
+		{name: "no contract"},
+		{
+			name: "several contracts",
+			contratos: []proofWindowContract{
+				{id: "a", blocksRemaining: 30, value: 10},
+				{id: "b", blocksRemaining: 5, value: 2.5},
+				{id: "c", blocksRemaining: 12, value: 0.5},
+			},
+			wantCount:    3,
+			wantValue:    13,
+			wantDeadline: true,
+			wantMin:      5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := make(chan prometheus.Metric, 100)
+			collectProofRisk(ch, tt.contratos)
+			close(ch)
+
+			values := make(map[*prometheus.Desc]float64)
+			var perContract int
+			for m := range ch {
+				var pb dto.Metric
+				if err := m.Write(&pb); err != nil {
+					t.Fatal(err)
+				}
+				if m.Desc() == hostdContractProofWindowBlocks {
+					perContract++
+					continue
+				}
+				values[m.Desc()] = pb.GetGauge().GetValue()
+			}
+			if perContract != len(tt.contratos) {
+				t.Errorf("%d contracts exported, want %d", perContract, len(tt.contratos))
+			}
+			if got := values[hostdContractsInProofWindow]; got != tt.wantCount {
+				t.Errorf("contracts in proof window = %v, want %v", got, tt.wantCount)
+			}
+			if got := values[hostdProofValueAtRisk]; got != tt.wantValue {
+				t.Errorf("value at risk = %v, want %v", got, tt.wantValue)
+			}
+			got, ok := values[hostdProofWindowMinRemaining]
+			if ok != tt.wantDeadline || got != tt.wantMin {
+				t.Errorf("min blocks remaining = %v (exported %v), want %v (exported %v)", got, ok, tt.wantMin, tt.wantDeadline)
+			}
+		})
+	}
+}
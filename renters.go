@@ -0,0 +1,93 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// otherRenters is the renter label of the renters beyond the top N
+const otherRenters = "other"
+
+var (
+	hostdRenters                = newDesc("hostd_renters_count", "Number of renters with an active or renewed contract")
+	hostdRenterContracts        = newDesc("hostd_renter_active_contracts", "Number of active contracts of the renter", "renter")
+	hostdRenterStoredBytes      = newDesc("hostd_renter_stored_bytes", "Amount of data stored by the renter in its active contracts in bytes", "renter")
+	hostdRenterLockedCollateral = newDesc("hostd_renter_locked_collateral", "Collateral locked in the active contracts of the renter", "renter")
+	hostdRenterRevenue          = newDesc("hostd_renter_revenue_potential", "Potential revenue of the contracts of the renter", "renter")
+)
+
+// renterStats aggregates the contracts of one renter
+type renterStats struct {
+	renter           string
+	contracts        int
+	storedBytes      uint64
+	lockedCollateral float64
+	revenue          float64
+}
+
+// renterBreakdown holds the renters bringing the most potential revenue, the
+// others being summed in a single bucket
+type renterBreakdown struct {
+	renters int
+	top     []renterStats
+	other   renterStats
+}
+
+// newRenterBreakdown aggregates the contracts by renter. The revenue covers
+// the same contracts as the forecasts, while the renewed contracts are left
+// out of the data and collateral counted for their successor.
+func newRenterBreakdown(contratos []contract, topN int) *renterBreakdown {
+	byRenter := make(map[string]*renterStats)
+	for _, c := range contratos {
+		r, ok := byRenter[c.RenterKey]
+		if !ok {
+			r = &renterStats{renter: c.RenterKey}
+			byRenter[c.RenterKey] = r
+		}
+		r.revenue += c.Revenue.total()
+		if c.Active && !c.Renewed {
+			r.contracts++
+			r.storedBytes += c.Filesize
+			r.lockedCollateral += c.LockedCollateral
+		}
+	}
+
+	renters := make([]renterStats, 0, len(byRenter))
+	for _, r := range byRenter {
+		renters = append(renters, *r)
+	}
+	sort.Slice(renters, func(i, j int) bool {
+		if renters[i].revenue != renters[j].revenue {
+			return renters[i].revenue > renters[j].revenue
+		}
+		return renters[i].renter < renters[j].renter
+	})
+
+	b := &renterBreakdown{renters: len(renters), other: renterStats{renter: otherRenters}}
+	for i, r := range renters {
+		if i < topN {
+			b.top = append(b.top, r)
+			continue
+		}
+		b.other.contracts += r.contracts
+		b.other.storedBytes += r.storedBytes
+		b.other.lockedCollateral += r.lockedCollateral
+		b.other.revenue += r.revenue
+	}
+	return b
+}
+
+// collectRenters exports the per-renter breakdown of the contracts
+func collectRenters(ch chan<- prometheus.Metric, b *renterBreakdown) {
+	ch <- prometheus.MustNewConstMetric(hostdRenters, prometheus.GaugeValue, float64(b.renters))
+	// the other bucket is always exported so that the sum over renters is
+	// stable when renters enter or leave the top
+	renters := append(append([]renterStats(nil), b.top...), b.other)
+	for _, r := range renters {
+		ch <- prometheus.MustNewConstMetric(hostdRenterContracts, prometheus.GaugeValue, float64(r.contracts), r.renter)
+		ch <- prometheus.MustNewConstMetric(hostdRenterStoredBytes, prometheus.GaugeValue, float64(r.storedBytes), r.renter)
+		ch <- prometheus.MustNewConstMetric(hostdRenterLockedCollateral, prometheus.GaugeValue, r.lockedCollateral, r.renter)
+		ch <- prometheus.MustNewConstMetric(hostdRenterRevenue, prometheus.GaugeValue, r.revenue, r.renter)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build linux
+
+package main
+
+import (
+	"syscall"
+)
+
+// statVolume reads the filesystem holding the volume file at path and the
+// disk space used by the file
+func statVolume(path string) (volumeFilesystem, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return volumeFilesystem{}, err
+	}
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return volumeFilesystem{}, err
+	}
+	return volumeFilesystem{
+		size:       fs.Blocks * uint64(fs.Bsize),
+		free:       fs.Bavail * uint64(fs.Bsize),
+		freeInodes: fs.Ffree,
+		// st_blocks is always counted in 512 byte units
+		allocated: uint64(st.Blocks) * 512,
+	}, nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+)
+
+// statVolume is only implemented on linux
+func statVolume(path string) (volumeFilesystem, error) {
+	return volumeFilesystem{}, errors.New("comparing volumes with the filesystem is only supported on linux")
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// target is a hostd instance polled by the exporter
+type target struct {
+	Name         string            `yaml:"name"`
+	Address      string            `yaml:"address"`
+	Password     string            `yaml:"password"`
+	PasswordFile string            `yaml:"password_file"`
+	Labels       map[string]string `yaml:"labels"`
+	// StatVolumes compares the volumes with the local filesystem, for a
+	// hostd running on the same machine as the exporter
+	StatVolumes bool `yaml:"stat_volumes"`
+}
+
+// password returns the API password of the target, reading it from
+// PasswordFile when set
+func (t target) password() (string, error) {
+	if t.PasswordFile == "" {
+		return t.Password, nil
+	}
+	b, err := os.ReadFile(t.PasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file of target %q: %w", t.Name, err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// labels returns the labels added to every metric of the target
+func (t target) labels() prometheus.Labels {
+	labels := prometheus.Labels{"host": t.Name}
+	for k, v := range t.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// collectorKey identifies the targets that can share a collector: the same
+// hostd polled with the same credentials and settings
+type collectorKey struct {
+	address     string
+	password    string
+	statVolumes bool
+}
+
+// collectorKey returns the key of the collector polling the target
+func (t target) collectorKey() (collectorKey, error) {
+	passwd, err := t.password()
+	if err != nil {
+		return collectorKey{}, err
+	}
+	return collectorKey{address: t.Address, password: passwd, statVolumes: t.StatVolumes}, nil
+}
+
+// newCollector returns a collector polling the hostd of key with the settings
+// of cfg
+func (key collectorKey) newCollector(cfg *config) *HostdCollector {
+	collector := NewHostdCollector(key.address, key.password, cfg)
+	collector.statVolumes = key.statVolumes
+	return collector
+}
+
+// keyValueFlag is a repeatable flag holding name=value pairs
+type keyValueFlag struct {
+	keys   []string
+	values map[string]string
+}
+
+func (f *keyValueFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(f.keys))
+	for _, k := range f.keys {
+		pairs = append(pairs, k+"="+f.values[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f *keyValueFlag) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" || value == "" {
+		return errors.New("expected name=value")
+	}
+	if f.values == nil {
+		f.values = make(map[string]string)
+	}
+	if _, exists := f.values[key]; exists {
+		return fmt.Errorf("%q is set more than once", key)
+	}
+	f.keys = append(f.keys, key)
+	f.values[key] = value
+	return nil
+}
+
+// parseTargets builds the target list from the -target and -target.passwd-file
+// flags. Without any -target the single hostd at address is polled, named after
+// its address.
+func parseTargets(addresses, passwdFiles *keyValueFlag, address, passwd string) ([]target, error) {
+	if len(addresses.keys) == 0 {
+		if len(passwdFiles.keys) != 0 {
+			return nil, errors.New("-target.passwd-file requires -target")
+		}
+		return []target{{Name: address, Address: address, Password: passwd}}, nil
+	}
+
+	for _, name := range passwdFiles.keys {
+		if _, ok := addresses.values[name]; !ok {
+			return nil, fmt.Errorf("password file given for unknown target %q", name)
+		}
+	}
+
+	targets := make([]target, 0, len(addresses.keys))
+	for _, name := range addresses.keys {
+		targets = append(targets, target{
+			Name:         name,
+			Address:      addresses.values[name],
+			Password:     passwd,
+			PasswordFile: passwdFiles.values[name],
+		})
+	}
+	return targets, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.sia.tech/hostd/v2/api"
+	"go.sia.tech/hostd/v2/host/storage"
+)
+
+var volumeOperationLabels = append(volumeLabels, "operation")
+
+var (
+	hostdVolumeOperationStart     = newDesc("hostd_volume_operation_start_timestamp_seconds", "Unix time the exporter first saw the volume operation", volumeOperationLabels...)
+	hostdVolumeOperationRate      = newDesc("hostd_volume_operation_sectors_per_second", "Average number of sectors migrated or resized per second since the start of the operation", volumeOperationLabels...)
+	hostdVolumeOperationRemaining = newDesc("hostd_volume_operation_sectors_remaining", "Number of sectors left to migrate out of the removed volume", volumeOperationLabels...)
+	hostdVolumeOperationProgress  = newDesc("hostd_volume_operation_progress_ratio", "Share of the sectors of the removed volume migrated since the start of the operation", volumeOperationLabels...)
+	hostdVolumeOperationETA       = newDesc("hostd_volume_operation_estimated_completion_timestamp_seconds", "Unix time the removal of the volume is expected to finish at the current rate", volumeOperationLabels...)
+)
+
+// volumeOperation is a resize or removal of a volume in progress. sectors is
+// the quantity the operation moves: the used sectors of a removed volume,
+// which go down to 0, or the total sectors of a resized volume, whose target
+// is not reported by hostd.
+type volumeOperation struct {
+	id        int64
+	localPath string
+	operation string
+
+	start        time.Time
+	startSectors uint64
+	updated      time.Time
+	sectors      uint64
+}
+
+// rate returns the average number of sectors moved per second since the
+// operation was first seen
+func (o volumeOperation) rate() float64 {
+	elapsed := o.updated.Sub(o.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return math.Abs(float64(o.sectors)-float64(o.startSectors)) / elapsed
+}
+
+// volumeOperationTracker follows the volume operations from poll to poll
+type volumeOperationTracker struct {
+	mu  sync.Mutex
+	ops map[int64]*volumeOperation
+}
+
+// observe records the state of the volumes and returns the operations in
+// progress. Operations that are no longer reported are forgotten.
+func (t *volumeOperationTracker) observe(volumes []api.VolumeMeta, now time.Time) []volumeOperation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ops := make(map[int64]*volumeOperation)
+	var res []volumeOperation
+	for _, v := range volumes {
+		var sectors uint64
+		switch status := string(v.Status); status {
+		case string(storage.VolumeStatusRemoving):
+			sectors = v.UsedSectors
+		case string(storage.VolumeStatusResizing):
+			sectors = v.TotalSectors
+		default:
+			continue
+		}
+
+		op, ok := t.ops[v.ID]
+		if !ok || op.operation != string(v.Status) {
+			op = &volumeOperation{
+				id:           v.ID,
+				operation:    string(v.Status),
+				start:        now,
+				startSectors: sectors,
+			}
+		}
+		op.localPath = v.LocalPath
+		op.updated = now
+		op.sectors = sectors
+		ops[v.ID] = op
+		res = append(res, *op)
+	}
+	t.ops = ops
+	return res
+}
+
+// collectVolumeOperations exports the progress of the volume operations
+func collectVolumeOperations(ch chan<- prometheus.Metric, ops []volumeOperation) {
+	for _, op := range ops {
+		labels := []string{strconv.FormatInt(op.id, 10), pathLabel(op.localPath), op.operation}
+		gauge := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+		}
+
+		rate := op.rate()
+		gauge(hostdVolumeOperationStart, float64(op.start.Unix()))
+		gauge(hostdVolumeOperationRate, rate)
+		if op.operation != string(storage.VolumeStatusRemoving) {
+			continue
+		}
+
+		// a removal is done once every sector is migrated out of the volume
+		gauge(hostdVolumeOperationRemaining, float64(op.sectors))
+		progress := 1.0
+		if op.startSectors > 0 {
+			progress = 1 - float64(op.sectors)/float64(op.startSectors)
+		}
+		gauge(hostdVolumeOperationProgress, progress)
+		if rate > 0 {
+			eta := op.updated.Add(time.Duration(float64(op.sectors) / rate * float64(time.Second)))
+			gauge(hostdVolumeOperationETA, float64(eta.Unix()))
+		}
+	}
+}
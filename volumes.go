@@ -0,0 +1,115 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	rhp4 "go.sia.tech/core/rhp/v4"
+	"go.sia.tech/hostd/v2/api"
+	"go.sia.tech/hostd/v2/host/storage"
+)
+
+var volumeLabels = []string{"volume", "local_path"}
+
+// pathLabel returns the local_path label of a volume. Label values must be
+// valid UTF-8, which a path does not have to be.
+func pathLabel(path string) string {
+	return strings.ToValidUTF8(path, "\uFFFD")
+}
+
+var (
+	hostdVolumeTotalSectors     = newDesc("hostd_volume_total_sectors", "Number of sectors the volume can hold", volumeLabels...)
+	hostdVolumeUsedSectors      = newDesc("hostd_volume_used_sectors", "Number of sectors stored in the volume", volumeLabels...)
+	hostdVolumeReadOnly         = newDesc("hostd_volume_read_only", "Whether the volume is read-only", volumeLabels...)
+	hostdVolumeAvailable        = newDesc("hostd_volume_available", "Whether the volume is available to hostd", volumeLabels...)
+	hostdVolumeStatus           = newDesc("hostd_volume_status", "Status of the volume, 1 for the current status", append(volumeLabels, "status")...)
+	hostdVolumeErrors           = newDesc("hostd_volume_errors", "Number of errors reported by hostd for the volume", volumeLabels...)
+	hostdVolumeFailedReads      = newDesc("hostd_volume_failed_reads_total", "Number of failed sector reads of the volume", volumeLabels...)
+	hostdVolumeFailedWrites     = newDesc("hostd_volume_failed_writes_total", "Number of failed sector writes of the volume", volumeLabels...)
+	hostdVolumeSuccessfulReads  = newDesc("hostd_volume_successful_reads_total", "Number of successful sector reads of the volume", volumeLabels...)
+	hostdVolumeSuccessfulWrites = newDesc("hostd_volume_successful_writes_total", "Number of successful sector writes of the volume", volumeLabels...)
+
+	hostdVolumeFilesystemSize       = newDesc("hostd_volume_filesystem_size_bytes", "Size of the filesystem holding the volume file", volumeLabels...)
+	hostdVolumeFilesystemFree       = newDesc("hostd_volume_filesystem_free_bytes", "Free space of the filesystem holding the volume file", volumeLabels...)
+	hostdVolumeFilesystemFreeInodes = newDesc("hostd_volume_filesystem_free_inodes", "Free inodes of the filesystem holding the volume file", volumeLabels...)
+	hostdVolumeFileAllocated        = newDesc("hostd_volume_file_allocated_bytes", "Disk space used by the volume file", volumeLabels...)
+	hostdVolumeFilesystemHeadroom   = newDesc("hostd_volume_filesystem_headroom_bytes", "Free space of the filesystem left once the volume file uses its whole size, negative when the volume cannot be filled", volumeLabels...)
+)
+
+// volumeFilesystem is the state of the filesystem holding a volume file
+type volumeFilesystem struct {
+	size       uint64
+	free       uint64
+	freeInodes uint64
+	// allocated is the disk space used by the volume file, which is less
+	// than the volume size while the file is sparse
+	allocated uint64
+}
+
+// statVolumes reads the filesystem of every volume. Volumes whose local path
+// cannot be read are left out.
+func statVolumes(volumes []api.VolumeMeta) map[int64]volumeFilesystem {
+	filesystems := make(map[int64]volumeFilesystem)
+	for _, v := range volumes {
+		fs, err := statVolume(v.LocalPath)
+		if err != nil {
+			log.Printf("failed to stat volume %d at %q: %v", v.ID, v.LocalPath, err)
+			continue
+		}
+		filesystems[v.ID] = fs
+	}
+	return filesystems
+}
+
+// volumeStatuses are the values of the status label of hostd_volume_status
+var volumeStatuses = []string{
+	string(storage.VolumeStatusUnavailable),
+	string(storage.VolumeStatusCreating),
+	string(storage.VolumeStatusResizing),
+	string(storage.VolumeStatusRemoving),
+	string(storage.VolumeStatusReady),
+}
+
+// collectVolumes exports the state of every volume of the host, compared with
+// its filesystem when filesystems is set
+func collectVolumes(ch chan<- prometheus.Metric, volumes []api.VolumeMeta, filesystems map[int64]volumeFilesystem) {
+	for _, v := range volumes {
+		labels := []string{strconv.FormatInt(v.ID, 10), pathLabel(v.LocalPath)}
+		gauge := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+		}
+		counter := func(desc *prometheus.Desc, value uint64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value), labels...)
+		}
+
+		gauge(hostdVolumeTotalSectors, float64(v.TotalSectors))
+		gauge(hostdVolumeUsedSectors, float64(v.UsedSectors))
+		gauge(hostdVolumeReadOnly, boolToFloat64(v.ReadOnly))
+		gauge(hostdVolumeAvailable, boolToFloat64(v.Available))
+		gauge(hostdVolumeErrors, float64(len(v.Errors)))
+		for _, status := range volumeStatuses {
+			ch <- prometheus.MustNewConstMetric(hostdVolumeStatus, prometheus.GaugeValue, boolToFloat64(string(v.Status) == status), append(labels, status)...)
+		}
+
+		counter(hostdVolumeFailedReads, v.FailedReads)
+		counter(hostdVolumeFailedWrites, v.FailedWrites)
+		counter(hostdVolumeSuccessfulReads, v.SuccessfulReads)
+		counter(hostdVolumeSuccessfulWrites, v.SuccessfulWrites)
+
+		fs, ok := filesystems[v.ID]
+		if !ok {
+			continue
+		}
+		gauge(hostdVolumeFilesystemSize, float64(fs.size))
+		gauge(hostdVolumeFilesystemFree, float64(fs.free))
+		gauge(hostdVolumeFilesystemFreeInodes, float64(fs.freeInodes))
+		gauge(hostdVolumeFileAllocated, float64(fs.allocated))
+		// the part of the volume not yet written to disk still has to fit
+		// in the free space
+		unallocated := float64(v.TotalSectors*rhp4.SectorSize) - float64(fs.allocated)
+		gauge(hostdVolumeFilesystemHeadroom, float64(fs.free)-max(unallocated, 0))
+	}
+}
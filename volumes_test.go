@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"go.sia.tech/hostd/v2/api"
+	"go.sia.tech/hostd/v2/host/storage"
+)
+
+func TestCollectVolumesInvalidPath(t *testing.T) {
+	var v api.VolumeMeta
+	v.ID = 1
+	v.LocalPath = "/mnt/disk\xff/hostd.dat"
+	v.Status = storage.VolumeStatusRemoving
+
+	ch := make(chan prometheus.Metric, 100)
+	collectVolumes(ch, []api.VolumeMeta{v}, nil)
+	collectVolumeOperations(ch, []volumeOperation{{id: v.ID, localPath: v.LocalPath, operation: string(v.Status)}})
+	close(ch)
+
+	const want = "/mnt/disk�/hostd.dat"
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatalf("%s: %v", m.Desc(), err)
+		}
+		for _, l := range pb.GetLabel() {
+			if l.GetName() == "local_path" && l.GetValue() != want {
+				t.Errorf("%s: local_path = %q, want %q", m.Desc(), l.GetValue(), want)
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"math"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/wallet"
+)
+
+// walletEventsPageSize is the number of wallet events requested at a time
+const walletEventsPageSize = 100
+
+var (
+	walletEvents         = newDesc("hostd_wallet_events_total", "Number of confirmed wallet events", "type")
+	walletEventsInflow   = newDesc("hostd_wallet_inflow_siacoins_total", "SC received by the wallet in confirmed events", "type")
+	walletEventsOutflow  = newDesc("hostd_wallet_outflow_siacoins_total", "SC sent by the wallet in confirmed events", "type")
+	walletEventsFees     = newDesc("hostd_wallet_fees_siacoins_total", "Miner fees of the confirmed wallet transactions in SC", "type")
+	walletPending        = newDesc("hostd_wallet_pending_transactions", "Number of wallet transactions waiting for confirmation")
+	walletPendingInflow  = newDesc("hostd_wallet_pending_inflow_siacoins", "SC received by the wallet transactions waiting for confirmation")
+	walletPendingOutflow = newDesc("hostd_wallet_pending_outflow_siacoins", "SC sent by the wallet transactions waiting for confirmation")
+)
+
+// walletEventTotals accumulates the wallet events of one type
+type walletEventTotals struct {
+	count   float64
+	inflow  float64
+	outflow float64
+	fees    float64
+}
+
+// walletEventTracker counts the confirmed wallet events from poll to poll.
+// Each event is counted once, the first time it is seen.
+type walletEventTracker struct {
+	mu     sync.Mutex
+	seen   map[types.Hash256]bool
+	byType map[string]walletEventTotals
+	// complete is set once every event of the wallet has been read
+	complete bool
+}
+
+// eventFees returns the miner fees of a transaction event
+func eventFees(e wallet.Event) (fees types.Currency) {
+	switch data := e.Data.(type) {
+	case wallet.EventV1Transaction:
+		for _, fee := range data.Transaction.MinerFees {
+			fees = fees.Add(fee)
+		}
+	case wallet.EventV2Transaction:
+		fees = data.MinerFee
+	}
+	return fees
+}
+
+// update reads the wallet events that were not counted yet with fetch, the
+// events endpoint of the hostd API, and returns the counters. Events are
+// listed newest first, but an event can be confirmed after a more recent
+// immature payout, so the walk only stops after a whole page of already
+// counted events.
+func (t *walletEventTracker) update(fetch func(limit, offset int) ([]wallet.Event, error)) (map[string]walletEventTotals, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.seen == nil {
+		t.seen = make(map[types.Hash256]bool)
+		t.byType = make(map[string]walletEventTotals)
+	}
+
+	// the events endpoint does not report a total, the walk ends on the
+	// first empty page
+	it := newPageIterator(func(limit, offset int) ([]wallet.Event, int, error) {
+		events, err := fetch(limit, offset)
+		return events, math.MaxInt, err
+	}, walletEventsPageSize)
+	pageSeen := 0
+	for it.Next() {
+		e := it.Item()
+		if t.seen[e.ID] {
+			if pageSeen++; t.complete && pageSeen == walletEventsPageSize {
+				break
+			}
+			continue
+		}
+		pageSeen = 0
+		t.seen[e.ID] = true
+
+		totals := t.byType[e.Type]
+		totals.count++
+		totals.inflow += convertCurrency(e.SiacoinInflow())
+		totals.outflow += convertCurrency(e.SiacoinOutflow())
+		totals.fees += convertCurrency(eventFees(e))
+		t.byType[e.Type] = totals
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	t.complete = true
+
+	byType := make(map[string]walletEventTotals, len(t.byType))
+	for typ, totals := range t.byType {
+		byType[typ] = totals
+	}
+	return byType, nil
+}
+
+// collectWalletEvents exports the wallet event counters by event type
+func collectWalletEvents(ch chan<- prometheus.Metric, byType map[string]walletEventTotals) {
+	for typ, totals := range byType {
+		ch <- prometheus.MustNewConstMetric(walletEvents, prometheus.CounterValue, totals.count, typ)
+		ch <- prometheus.MustNewConstMetric(walletEventsInflow, prometheus.CounterValue, totals.inflow, typ)
+		ch <- prometheus.MustNewConstMetric(walletEventsOutflow, prometheus.CounterValue, totals.outflow, typ)
+		ch <- prometheus.MustNewConstMetric(walletEventsFees, prometheus.CounterValue, totals.fees, typ)
+	}
+}
+
+// collectPendingTransactions exports the wallet transactions waiting for
+// confirmation
+func collectPendingTransactions(ch chan<- prometheus.Metric, pending []wallet.Event) {
+	var inflow, outflow float64
+	for _, e := range pending {
+		inflow += convertCurrency(e.SiacoinInflow())
+		outflow += convertCurrency(e.SiacoinOutflow())
+	}
+	ch <- prometheus.MustNewConstMetric(walletPending, prometheus.GaugeValue, float64(len(pending)))
+	ch <- prometheus.MustNewConstMetric(walletPendingInflow, prometheus.GaugeValue, inflow)
+	ch <- prometheus.MustNewConstMetric(walletPendingOutflow, prometheus.GaugeValue, outflow)
+}
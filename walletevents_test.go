@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/wallet"
+)
+
+// testEvents returns the miner payouts with the given ids, in order
+func testEvents(ids ...int) []wallet.Event {
+	events := make([]wallet.Event, 0, len(ids))
+	for _, id := range ids {
+		events = append(events, wallet.Event{
+			ID:   types.Hash256{byte(id), byte(id >> 8)},
+			Type: wallet.EventTypeMinerPayout,
+			Data: wallet.EventPayout{},
+		})
+	}
+	return events
+}
+
+// eventRange returns the ids from first down to last, newest first
+func eventRange(first, last int) []int {
+	var ids []int
+	for id := first; id >= last; id-- {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestWalletEventTracker(t *testing.T) {
+	// every step polls the events of the wallet with the same tracker
+	steps := []struct {
+		name   string
+		events []int
+		failAt int
+		// wantOffsets are the pages requested, of walletEventsPageSize
+		wantOffsets []int
+		wantCount   float64
+		wantErr     bool
+	}{
+		{
+			name:        "first walk interrupted",
+			events:      eventRange(250, 1),
+			failAt:      100,
+			wantOffsets: []int{0, 100},
+			wantErr:     true,
+		},
+		{
+			// the tracker is not complete yet, so the counted events of
+			// the first page do not stop the walk
+			name:        "first walk",
+			events:      eventRange(250, 1),
+			wantOffsets: []int{0, 100, 200, 250},
+			wantCount:   250,
+		},
+		{
+			name:        "nothing new",
+			events:      eventRange(250, 1),
+			wantOffsets: []int{0},
+			wantCount:   250,
+		},
+		{
+			// the walk stops after a whole page of counted events, which
+			// spans two pages here
+			name:        "new events",
+			events:      eventRange(253, 1),
+			wantOffsets: []int{0, 100},
+			wantCount:   253,
+		},
+		{
+			// an event confirmed after more recent ones resets the run
+			// of counted events
+			name:        "late event",
+			events:      append(eventRange(253, 200), append([]int{1000}, eventRange(199, 1)...)...),
+			wantOffsets: []int{0, 100},
+			wantCount:   254,
+		},
+	}
+
+	var tracker walletEventTracker
+	for _, step := range steps {
+		// the events endpoint does not report a total
+		src := &fakePages[wallet.Event]{items: testEvents(step.events...), failAt: step.failAt}
+		byType, err := tracker.update(func(limit, offset int) ([]wallet.Event, error) {
+			events, _, err := src.fetch(limit, offset)
+			return events, err
+		})
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: update() error = %v, want error %v", step.name, err, step.wantErr)
+		}
+		if !reflect.DeepEqual(src.offsets, step.wantOffsets) {
+			t.Errorf("%s: offsets = %v, want %v", step.name, src.offsets, step.wantOffsets)
+		}
+		if err != nil {
+			continue
+		}
+		if got := byType[wallet.EventTypeMinerPayout].count; got != step.wantCount {
+			t.Errorf("%s: count = %v, want %v", step.name, got, step.wantCount)
+		}
+	}
+}